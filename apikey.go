@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// apiKeySourceTimeout bounds the command run for an exec: API key source.
+const apiKeySourceTimeout = 30 * time.Second
+
+// API key source kinds accepted by api_key_source.
+const (
+	// APIKeySourceEnv reads the key from an environment variable.
+	APIKeySourceEnv = "env"
+	// APIKeySourceFile reads the key from a file.
+	APIKeySourceFile = "file"
+	// APIKeySourceExec reads the key from the output of a command.
+	APIKeySourceExec = "exec"
+)
+
+// parseAPIKeySource splits an api_key_source into its kind and value.
+func parseAPIKeySource(spec string) (string, string, error) {
+	kind, value, ok := strings.Cut(spec, ":")
+	value = strings.TrimSpace(value)
+	if !ok || value == "" {
+		return "", "", fmt.Errorf("api_key_source must be one of: env:NAME, file:/path, exec:command")
+	}
+	switch kind {
+	case APIKeySourceEnv, APIKeySourceFile:
+		return kind, value, nil
+	case APIKeySourceExec:
+		if _, err := splitCommand(value); err != nil {
+			return "", "", fmt.Errorf("api_key_source exec command: %v", err)
+		}
+		return kind, value, nil
+	default:
+		return "", "", fmt.Errorf("unknown api_key_source kind %q: must be one of: env, file, exec", kind)
+	}
+}
+
+// resolveAPIKey reads the API key from an api_key_source. Surrounding
+// whitespace is trimmed. Errors name the source but never include its
+// contents. Commands are split into words by splitCommand and run without a
+// shell.
+func (p *ChocolateyPlugin) resolveAPIKey(ctx context.Context, spec string) (string, error) {
+	kind, value, err := parseAPIKeySource(spec)
+	if err != nil {
+		return "", err
+	}
+
+	var apiKey string
+	switch kind {
+	case APIKeySourceEnv:
+		apiKey = os.Getenv(value)
+	case APIKeySourceFile:
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return "", fmt.Errorf("API key source %s: cannot read file: %v", spec, err)
+		}
+		apiKey = string(data)
+	case APIKeySourceExec:
+		args, _ := splitCommand(value)
+		execCtx, cancel := context.WithTimeout(ctx, apiKeySourceTimeout)
+		defer cancel()
+		output, err := p.getKeyExecutor().Run(execCtx, args[0], args[1:]...)
+		if err != nil {
+			return "", fmt.Errorf("API key source %s: command failed: %v", spec, err)
+		}
+		apiKey = string(output)
+	}
+
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return "", fmt.Errorf("API key source %s: resolved to an empty key", spec)
+	}
+	return apiKey, nil
+}
+
+// splitCommand splits a command line into words the way a POSIX shell does,
+// without expanding anything: words are separated by unquoted whitespace,
+// single quotes keep their contents literally, double quotes keep their
+// contents except that a backslash escapes a following ", \, $ or `, and a
+// backslash outside quotes escapes the next character.
+func splitCommand(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("\"\\$`", command[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(command[i])
+			}
+			if i == len(command) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+		case c == '\\':
+			if i+1 == len(command) {
+				return nil, fmt.Errorf("trailing backslash")
+			}
+			i++
+			word.WriteByte(command[i])
+		default:
+			word.WriteByte(c)
+		}
+		inWord = true
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	if len(words) == 0 || words[0] == "" {
+		return nil, fmt.Errorf("empty command")
+	}
+	return words, nil
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestParseAPIKeySource(t *testing.T) {
+	tests := []struct {
+		spec      string
+		kind      string
+		value     string
+		errSubstr string
+	}{
+		{spec: "env:CHOCO_KEY", kind: APIKeySourceEnv, value: "CHOCO_KEY"},
+		{spec: "file:/run/secrets/choco", kind: APIKeySourceFile, value: "/run/secrets/choco"},
+		{spec: "exec:op read op://ci/choco/key", kind: APIKeySourceExec, value: "op read op://ci/choco/key"},
+		{spec: "CHOCO_KEY", errSubstr: "must be one of: env:NAME, file:/path, exec:command"},
+		{spec: "file: ", errSubstr: "must be one of: env:NAME, file:/path, exec:command"},
+		{spec: "vault:kv/choco", errSubstr: `unknown api_key_source kind "vault"`},
+		{spec: `exec:op read "op://ci/My Item`, errSubstr: "api_key_source exec command: unterminated double quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			kind, value, err := parseAPIKeySource(tt.spec)
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Errorf("expected error containing '%s', got %v", tt.errSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if kind != tt.kind || value != tt.value {
+				t.Errorf("expected %s/%s, got %s/%s", tt.kind, tt.value, kind, value)
+			}
+		})
+	}
+}
+
+func TestResolveAPIKey(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "choco-key")
+	if err := os.WriteFile(keyFile, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	emptyFile := filepath.Join(dir, "empty")
+	if err := os.WriteFile(emptyFile, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CHOCO_TEST_KEY", "env-secret")
+
+	tests := []struct {
+		name      string
+		spec      string
+		output    string
+		err       error
+		expected  string
+		errSubstr string
+		wantRun   []string
+	}{
+		{name: "env", spec: "env:CHOCO_TEST_KEY", expected: "env-secret"},
+		{name: "env unset", spec: "env:CHOCO_TEST_UNSET_KEY", errSubstr: "API key source env:CHOCO_TEST_UNSET_KEY: resolved to an empty key"},
+		{name: "file", spec: "file:" + keyFile, expected: "file-secret"},
+		{name: "file missing", spec: "file:" + filepath.Join(dir, "missing"), errSubstr: "cannot read file"},
+		{name: "file empty", spec: "file:" + emptyFile, errSubstr: "resolved to an empty key"},
+		{name: "exec", spec: "exec:pass show choco/api-key", output: "exec-secret\n", expected: "exec-secret", wantRun: []string{"pass", "show", "choco/api-key"}},
+		{name: "exec with quoted argument", spec: `exec:op read "op://vault/My Item/credential"`, output: "exec-secret", expected: "exec-secret", wantRun: []string{"op", "read", "op://vault/My Item/credential"}},
+		{name: "exec failure", spec: "exec:vault kv get -field=key secret/choco", output: "exec-secret", err: errors.New("exit status 2"), errSubstr: "API key source exec:vault kv get -field=key secret/choco: command failed: exit status 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockCommandExecutor{Output: []byte(tt.output), Err: tt.err}
+			p := &ChocolateyPlugin{keyExecutor: mock}
+
+			apiKey, err := p.resolveAPIKey(context.Background(), tt.spec)
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Fatalf("expected error containing '%s', got %v", tt.errSubstr, err)
+				}
+				if strings.Contains(err.Error(), "secret\n") || strings.Contains(err.Error(), "exec-secret") {
+					t.Errorf("error leaks the secret: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if apiKey != tt.expected {
+				t.Errorf("expected key %q, got %q", tt.expected, apiKey)
+			}
+			if tt.wantRun != nil {
+				if len(mock.Commands) != 1 || mock.Commands[0].Name != tt.wantRun[0] || strings.Join(mock.Commands[0].Args, " ") != strings.Join(tt.wantRun[1:], " ") {
+					t.Errorf("expected command %v, got %v", tt.wantRun, mock.Commands)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command   string
+		expected  []string
+		errSubstr string
+	}{
+		{command: "pass show choco/api-key", expected: []string{"pass", "show", "choco/api-key"}},
+		{command: "  op\tread  x ", expected: []string{"op", "read", "x"}},
+		{command: `op read "op://vault/My Item/credential"`, expected: []string{"op", "read", "op://vault/My Item/credential"}},
+		{command: `sh -c 'echo "$KEY"'`, expected: []string{"sh", "-c", `echo "$KEY"`}},
+		{command: `printf "%s" "a \"quoted\" \\ \x"`, expected: []string{"printf", "%s", `a "quoted" \ \x`}},
+		{command: `cat My\ Key.txt`, expected: []string{"cat", "My Key.txt"}},
+		{command: `echo ""`, expected: []string{"echo", ""}},
+		{command: `get "key"'-'suffix`, expected: []string{"get", "key-suffix"}},
+		{command: `op read 'unterminated`, errSubstr: "unterminated single quote"},
+		{command: `op read "unterminated`, errSubstr: "unterminated double quote"},
+		{command: `op read \`, errSubstr: "trailing backslash"},
+		{command: `""`, errSubstr: "empty command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			words, err := splitCommand(tt.command)
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Errorf("expected error containing '%s', got %q, %v", tt.errSubstr, words, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(words, "|") != strings.Join(tt.expected, "|") || len(words) != len(tt.expected) {
+				t.Errorf("splitCommand(%s) = %q, want %q", tt.command, words, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateAPIKeySource(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     map[string]any
+		wantErrFld string
+		wantErrMsg string
+	}{
+		{name: "valid", config: map[string]any{"api_key_source": "file:/run/secrets/choco"}},
+		{name: "invalid", config: map[string]any{"api_key_source": "/run/secrets/choco"}, wantErrFld: "api_key_source", wantErrMsg: "api_key_source must be one of: env:NAME, file:/path, exec:command"},
+		{name: "with api_key", config: map[string]any{"api_key_source": "env:KEY", "api_key": "key"}, wantErrFld: "api_key_source", wantErrMsg: "api_key and api_key_source are mutually exclusive"},
+		{name: "source entry", config: map[string]any{"sources": []any{map[string]any{"url": "http://localhost:8080/", "api_key_source": "exec:op read op://ci/key"}}}},
+		{name: "invalid source entry", config: map[string]any{"sources": []any{map[string]any{"url": "http://localhost:8080/", "api_key_source": "op read"}}}, wantErrFld: "sources[0].api_key_source", wantErrMsg: "api_key_source must be one of: env:NAME, file:/path, exec:command"},
+	}
+
+	p := &ChocolateyPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{"package_path": "mypackage.{{version}}.nupkg"}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+
+			resp, err := p.Validate(context.Background(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErrFld == "" {
+				if !resp.Valid {
+					t.Errorf("expected valid, got errors: %v", resp.Errors)
+				}
+				return
+			}
+			if resp.Valid || len(resp.Errors) != 1 || resp.Errors[0].Field != tt.wantErrFld || resp.Errors[0].Message != tt.wantErrMsg {
+				t.Errorf("expected %s error '%s', got %v", tt.wantErrFld, tt.wantErrMsg, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestExecuteAPIKeySource(t *testing.T) {
+	t.Setenv("CHOCOLATEY_API_KEY", "ignored-env-key")
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	tests := []struct {
+		name      string
+		dryRun    bool
+		keyErr    error
+		wantError string
+		wantPush  bool
+	}{
+		{name: "resolved at push time", wantPush: true},
+		{name: "not resolved in dry run", dryRun: true},
+		{name: "resolution failure", keyErr: errors.New("exit status 1"), wantError: "API key source exec:op read op://ci/choco/key: command failed: exit status 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyExec := &MockCommandExecutor{Output: []byte("exec-secret\n"), Err: tt.keyErr}
+			mock := &MockCommandExecutor{Output: []byte("pushed exec-secret")}
+			p := &ChocolateyPlugin{cmdExecutor: mock, keyExecutor: keyExec, logger: discardLogger}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"package_path":   "mypackage.{{version}}.nupkg",
+					"source":         "http://localhost:8080/",
+					"api_key_source": "exec:op read op://ci/choco/key",
+				},
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+				DryRun:  tt.dryRun,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantError != "" {
+				if resp.Success || resp.Error != tt.wantError {
+					t.Errorf("expected error '%s', got %+v", tt.wantError, resp)
+				}
+				return
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got error: %s", resp.Error)
+			}
+			if tt.dryRun != (len(keyExec.Commands) == 0) {
+				t.Errorf("expected key resolution only outside dry run, got %v", keyExec.Commands)
+			}
+			if tt.wantPush {
+				if len(mock.Commands) != 1 || !containsString(mock.Commands[0].Args, "exec-secret") {
+					t.Errorf("expected the resolved key to be pushed with, got %v", mock.Commands)
+				}
+				if strings.Contains(resp.Outputs["output"].(string), "exec-secret") {
+					t.Errorf("resolved key escaped in output: %v", resp.Outputs["output"])
+				}
+			}
+		})
+	}
+}
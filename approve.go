@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Checks run by the pre-approve gate, reported in the "checks" output.
+const (
+	CheckVersion = "version"
+	CheckPackage = "package"
+	CheckNuspec  = "nuspec"
+	CheckFeed    = "feed"
+	CheckAPIKey  = "api_key"
+)
+
+// approvalCheck is the result of one pre-approve check.
+type approvalCheck struct {
+	Check string `json:"check"`
+	// Subject names the package path, nuspec or source checked.
+	Subject string `json:"subject,omitempty"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// approvalReport collects the checks of the pre-approve gate.
+type approvalReport struct {
+	checks []approvalCheck
+}
+
+// pass records a passed check.
+func (r *approvalReport) pass(check, subject, message string) {
+	r.checks = append(r.checks, approvalCheck{Check: check, Subject: subject, Passed: true, Message: message})
+}
+
+// fail records a failed check.
+func (r *approvalReport) fail(check, subject, message string) {
+	r.checks = append(r.checks, approvalCheck{Check: check, Subject: subject, Message: message})
+}
+
+// failures returns the failed checks as "check subject: message".
+func (r *approvalReport) failures() []string {
+	var failed []string
+	for _, c := range r.checks {
+		if c.Passed {
+			continue
+		}
+		name := c.Check
+		if c.Subject != "" {
+			name += " " + c.Subject
+		}
+		failed = append(failed, name+": "+c.Message)
+	}
+	return failed
+}
+
+// preApprove runs the offline checks of pre-publish and post-publish before
+// anything is published: the version normalizes, each package exists (or,
+// with pack, the nuspec builds), the nuspec passes the inspection and
+// validator rules that are enabled, the version is not already on any feed
+// unless on_exists allows it, and every API key resolves. All checks run so
+// the report lists every problem at once. Dry runs do not run exec: API key
+// sources, like post-publish.
+func (p *ChocolateyPlugin) preApprove(ctx context.Context, cfg *Config, releaseCtx plugin.ReleaseContext, dryRun bool) *plugin.ExecuteResponse {
+	report := &approvalReport{}
+	outputs := map[string]any{}
+
+	version, err := p.packageVersion(cfg, releaseCtx)
+	if err != nil {
+		report.fail(CheckVersion, releaseCtx.Version, err.Error())
+	} else {
+		report.pass(CheckVersion, releaseCtx.Version, version)
+		outputs["version"] = version
+
+		ids := p.checkPackages(report, cfg, releaseCtx, version)
+		p.checkFeeds(ctx, report, cfg, ids, version)
+	}
+	p.checkAPIKeys(ctx, report, cfg, dryRun)
+
+	outputs["checks"] = report.checks
+	if failed := report.failures(); len(failed) > 0 {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("%d of %d pre-approval check(s) failed: %s", len(failed), len(report.checks), strings.Join(failed, "; ")),
+			Outputs: outputs,
+		}
+	}
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("All %d Chocolatey pre-approval checks passed", len(report.checks)),
+		Outputs: outputs,
+	}
+}
+
+// checkPackages runs the package checks of pre-publish and post-publish and
+// returns the ids of the packages to look up on the feeds. With pack the
+// nuspec is built instead, since the package does not exist until
+// pre-publish.
+func (p *ChocolateyPlugin) checkPackages(report *approvalReport, cfg *Config, releaseCtx plugin.ReleaseContext, version string) []string {
+	if cfg.Pack {
+		packagePath := substitutePlaceholders(cfg.PackagePath, version, releaseCtx.TagName)
+		if err := validatePackagePath(packagePath); err != nil {
+			report.fail(CheckPackage, packagePath, err.Error())
+			return nil
+		}
+		report.pass(CheckPackage, packagePath, "built from the nuspec during pre-publish")
+
+		spec := checkNuspec(report, cfg, releaseCtx, version)
+		if spec == nil {
+			return nil
+		}
+		// post-publish inspects the package built from this manifest.
+		if cfg.Inspect {
+			checkInspection(report, packagePath, spec, checkMetadata(spec, packageIDFromPath(packagePath, version), version))
+		}
+		return []string{spec.Metadata.ID}
+	}
+	if cfg.ValidateNuspec {
+		checkNuspec(report, cfg, releaseCtx, version)
+	}
+
+	packagePaths, err := expandPackagePaths(cfg.PackagePaths, version, releaseCtx.TagName)
+	if err != nil {
+		report.fail(CheckPackage, strings.Join(cfg.PackagePaths, ", "), err.Error())
+		return nil
+	}
+	if len(packagePaths) == 0 {
+		report.fail(CheckPackage, "", "package path cannot be empty")
+		return nil
+	}
+
+	var ids []string
+	for _, packagePath := range packagePaths {
+		if err := validatePackagePath(packagePath); err != nil {
+			report.fail(CheckPackage, packagePath, err.Error())
+			continue
+		}
+		if _, err := os.Stat(packagePath); err != nil {
+			report.fail(CheckPackage, packagePath, fmt.Sprintf("package does not exist: %v", err))
+			continue
+		}
+		report.pass(CheckPackage, packagePath, "")
+
+		if cfg.Inspect {
+			spec, issues := inspectPackage(packagePath, packageIDFromPath(packagePath, version), version)
+			checkInspection(report, packagePath, spec, issues)
+		}
+		id, err := packageID(packagePath, version)
+		if err != nil {
+			for _, src := range planSources(cfg) {
+				report.feedNotChecked(cfg.OnExists, fmt.Sprintf("%s %s on %s", packagePath, version, src.URL), err)
+			}
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// checkNuspec builds nuspec_path like pre-publish, with templates and release
+// notes applied, and checks it against the community repository requirements
+// when validate_nuspec is set. It returns the built manifest, or nil if it
+// cannot be built.
+func checkNuspec(report *approvalReport, cfg *Config, releaseCtx plugin.ReleaseContext, version string) *nuspec {
+	if err := validateNuspecPath(cfg.NuspecPath); err != nil {
+		report.fail(CheckNuspec, cfg.NuspecPath, err.Error())
+		return nil
+	}
+	opts, err := releasePackOptions(cfg, releaseCtx, version)
+	if err != nil {
+		report.fail(CheckNuspec, cfg.NuspecPath, err.Error())
+		return nil
+	}
+	_, spec, err := buildManifest(cfg.NuspecPath, opts)
+	if err != nil {
+		report.fail(CheckNuspec, cfg.NuspecPath, err.Error())
+		return nil
+	}
+
+	if cfg.ValidateNuspec {
+		if failed := requirementViolations(runRules(spec.Metadata, cfg.DisabledRules)); len(failed) > 0 {
+			report.fail(CheckNuspec, cfg.NuspecPath, fmt.Sprintf("violates community repository requirement(s): %s", strings.Join(failed, ", ")))
+			return spec
+		}
+	}
+	report.pass(CheckNuspec, cfg.NuspecPath, fmt.Sprintf("%s %s", spec.Metadata.ID, version))
+	return spec
+}
+
+// checkInspection records the inspection post-publish runs with inspect.
+func checkInspection(report *approvalReport, packagePath string, spec *nuspec, issues []inspectionIssue) {
+	if len(issues) == 0 {
+		report.pass(CheckNuspec, packagePath, fmt.Sprintf("%s inspected", spec.Metadata.ID))
+		return
+	}
+	problems := make([]string, len(issues))
+	for i, issue := range issues {
+		problems[i] = issue.Message
+	}
+	report.fail(CheckNuspec, packagePath, strings.Join(problems, "; "))
+}
+
+// checkFeeds checks that no package version is already on a feed, unless
+// on_exists skips or overwrites it.
+func (p *ChocolateyPlugin) checkFeeds(ctx context.Context, report *approvalReport, cfg *Config, ids []string, version string) {
+	for _, id := range ids {
+		for _, src := range planSources(cfg) {
+			srcCfg := *cfg
+			srcCfg.Source = src.URL
+			if len(cfg.Sources) > 0 {
+				srcCfg.FeedURL = ""
+			}
+			subject := fmt.Sprintf("%s %s on %s", id, version, src.URL)
+
+			checkCtx, cancel := context.WithTimeout(ctx, time.Duration(src.Timeout)*time.Second)
+			exists, err := p.getNuGetClient().PackageExists(checkCtx, feedURL(&srcCfg), id, version)
+			cancel()
+
+			switch {
+			case err != nil:
+				report.feedNotChecked(cfg.OnExists, subject, fmt.Errorf("cannot query %s: %w", feedURL(&srcCfg), err))
+			case !exists:
+				report.pass(CheckFeed, subject, "version is not published yet")
+			case cfg.OnExists == OnExistsSkip:
+				report.pass(CheckFeed, subject, "version is already published and will be skipped")
+			case cfg.OnExists == OnExistsForce:
+				report.pass(CheckFeed, subject, "version is already published and will be overwritten")
+			default:
+				report.fail(CheckFeed, subject, "version is already published; bump the version or set on_exists to skip or force")
+			}
+		}
+	}
+}
+
+// feedNotChecked records a feed that could not be checked. Like post-plan,
+// which plans a push, it only fails with on_exists: skip, where post-publish
+// fails the push when it cannot check the feed either.
+func (r *approvalReport) feedNotChecked(onExists, subject string, err error) {
+	message := fmt.Sprintf("existence not checked: %v", err)
+	if onExists == OnExistsSkip {
+		r.fail(CheckFeed, subject, message)
+		return
+	}
+	r.pass(CheckFeed, subject, message)
+}
+
+// checkAPIKeys checks that an API key is configured or resolves for every
+// source. Resolved keys are discarded; post-publish resolves them again.
+func (p *ChocolateyPlugin) checkAPIKeys(ctx context.Context, report *approvalReport, cfg *Config, dryRun bool) {
+	if len(cfg.Sources) == 0 {
+		p.checkAPIKey(ctx, report, cfg.Source, cfg.APIKey, cfg.APIKeySource, "set api_key in config or CHOCOLATEY_API_KEY environment variable", dryRun)
+		return
+	}
+	for _, src := range cfg.Sources {
+		hint := "set api_key"
+		if src.APIKeyEnv != "" {
+			hint = fmt.Sprintf("set the %s environment variable", src.APIKeyEnv)
+		}
+		p.checkAPIKey(ctx, report, src.URL, src.APIKey, src.APIKeySource, hint, dryRun)
+	}
+}
+
+// checkAPIKey checks the API key of one source. An exec: source is only
+// checked to parse on dry runs, since its command may have side effects.
+func (p *ChocolateyPlugin) checkAPIKey(ctx context.Context, report *approvalReport, source, apiKey, apiKeySource, hint string, dryRun bool) {
+	switch {
+	case apiKey != "":
+		report.pass(CheckAPIKey, source, "configured")
+	case dryRun && strings.HasPrefix(apiKeySource, APIKeySourceExec+":"):
+		if _, _, err := parseAPIKeySource(apiKeySource); err != nil {
+			report.fail(CheckAPIKey, source, err.Error())
+		} else {
+			report.pass(CheckAPIKey, source, "not resolved in dry runs: "+apiKeySource)
+		}
+	case apiKeySource != "":
+		if _, err := p.resolveAPIKey(ctx, apiKeySource); err != nil {
+			report.fail(CheckAPIKey, source, err.Error())
+		} else {
+			report.pass(CheckAPIKey, source, "resolved from "+apiKeySource)
+		}
+	default:
+		report.fail(CheckAPIKey, source, "API key is required: "+hint)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestExecutePreApprove(t *testing.T) {
+	server := newTestFeed(t, "mypackage@1.0.0")
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		setup       func(t *testing.T, dir string)
+		config      map[string]any
+		version     string
+		wantSuccess bool
+		// wantFailed lists the failed checks as "check subject".
+		wantFailed []string
+	}{
+		{
+			name: "publishable",
+			setup: func(t *testing.T, dir string) {
+				buildTestPackage(t, dir, "mypackage.2.0.0.nupkg", testNuspec, "2.0.0")
+			},
+			version:     "v2.0.0",
+			wantSuccess: true,
+		},
+		{
+			name:       "missing package",
+			version:    "v2.0.0",
+			wantFailed: []string{"package mypackage.2.0.0.nupkg"},
+		},
+		{
+			name: "version collides",
+			setup: func(t *testing.T, dir string) {
+				buildTestPackage(t, dir, "mypackage.1.0.0.nupkg", testNuspec, "1.0.0")
+			},
+			version:    "v1.0.0",
+			wantFailed: []string{"feed mypackage 1.0.0 on http://localhost:8080/"},
+		},
+		{
+			name: "existing version skipped",
+			setup: func(t *testing.T, dir string) {
+				buildTestPackage(t, dir, "mypackage.1.0.0.nupkg", testNuspec, "1.0.0")
+			},
+			config:      map[string]any{"on_exists": "skip"},
+			version:     "v1.0.0",
+			wantSuccess: true,
+		},
+		{
+			name: "nuspec does not match release",
+			setup: func(t *testing.T, dir string) {
+				buildTestPackage(t, dir, "mypackage.2.0.0.nupkg", testNuspec, "1.9.0")
+			},
+			config:     map[string]any{"inspect": true},
+			version:    "v2.0.0",
+			wantFailed: []string{"nuspec mypackage.2.0.0.nupkg"},
+		},
+		{
+			name: "nuspec not inspected",
+			setup: func(t *testing.T, dir string) {
+				buildTestPackage(t, dir, "mypackage.2.0.0.nupkg", testNuspec, "1.9.0")
+			},
+			version:     "v2.0.0",
+			wantSuccess: true,
+		},
+		{
+			name: "pack with metadata failing inspection",
+			setup: func(t *testing.T, dir string) {
+				writeTestNuspec(t, dir, strings.Replace(testNuspec, "<authors>Relicta Team</authors>", "", 1), nil)
+			},
+			config:     map[string]any{"pack": true, "nuspec_path": "mypackage.nuspec", "inspect": true},
+			version:    "v2.0.0",
+			wantFailed: []string{"nuspec mypackage.2.0.0.nupkg"},
+		},
+		{
+			name: "pack with metadata not inspected",
+			setup: func(t *testing.T, dir string) {
+				writeTestNuspec(t, dir, strings.Replace(testNuspec, "<authors>Relicta Team</authors>", "", 1), nil)
+			},
+			config:      map[string]any{"pack": true, "nuspec_path": "mypackage.nuspec"},
+			version:     "v2.0.0",
+			wantSuccess: true,
+		},
+		{
+			name: "pack with release notes link but no URL",
+			setup: func(t *testing.T, dir string) {
+				writeTestNuspec(t, dir, testNuspec, nil)
+			},
+			config:     map[string]any{"pack": true, "nuspec_path": "mypackage.nuspec", "release_notes_mode": "link"},
+			version:    "v2.0.0",
+			wantFailed: []string{"nuspec mypackage.nuspec"},
+		},
+		{
+			name: "unreachable feed",
+			setup: func(t *testing.T, dir string) {
+				buildTestPackage(t, dir, "mypackage.1.0.0.nupkg", testNuspec, "1.0.0")
+			},
+			config:      map[string]any{"feed_url": server.URL + "/broken"},
+			version:     "v1.0.0",
+			wantSuccess: true,
+		},
+		{
+			name: "unreachable feed with on_exists skip",
+			setup: func(t *testing.T, dir string) {
+				buildTestPackage(t, dir, "mypackage.1.0.0.nupkg", testNuspec, "1.0.0")
+			},
+			config:     map[string]any{"feed_url": server.URL + "/broken", "on_exists": "skip"},
+			version:    "v1.0.0",
+			wantFailed: []string{"feed mypackage 1.0.0 on http://localhost:8080/"},
+		},
+		{
+			name: "pack checks the nuspec instead of the package",
+			setup: func(t *testing.T, dir string) {
+				writeTestNuspec(t, dir, testNuspec, nil)
+			},
+			config:      map[string]any{"pack": true, "nuspec_path": "mypackage.nuspec"},
+			version:     "v2.0.0",
+			wantSuccess: true,
+		},
+		{
+			name: "pack with a package path list",
+			setup: func(t *testing.T, dir string) {
+				writeTestNuspec(t, dir, testNuspec, nil)
+			},
+			config:      map[string]any{"pack": true, "nuspec_path": "mypackage.nuspec", "package_path": []any{"mypackage.{{version}}.nupkg"}},
+			version:     "v2.0.0",
+			wantSuccess: true,
+		},
+		{
+			name: "unresolvable API key",
+			setup: func(t *testing.T, dir string) {
+				buildTestPackage(t, dir, "mypackage.2.0.0.nupkg", testNuspec, "2.0.0")
+			},
+			config:     map[string]any{"api_key": "", "api_key_source": "env:PRE_APPROVE_TEST_MISSING_KEY"},
+			version:    "v2.0.0",
+			wantFailed: []string{"api_key http://localhost:8080/"},
+		},
+		{
+			name:       "every problem is reported",
+			config:     map[string]any{"api_key": "", "api_key_source": "file:missing-key.txt"},
+			version:    "not-a-version",
+			wantFailed: []string{"version not-a-version", "api_key http://localhost:8080/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := chdirTemp(t)
+			if tt.setup != nil {
+				tt.setup(t, dir)
+			}
+			config := map[string]any{
+				"package_path": "mypackage.{{version}}.nupkg",
+				"api_key":      "test-api-key",
+				"source":       "http://localhost:8080/",
+				"feed_url":     server.URL + "/api/v2",
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+
+			mock := &MockCommandExecutor{}
+			p := &ChocolateyPlugin{cmdExecutor: mock, httpClient: server.Client()}
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPreApprove,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: tt.version, TagName: tt.version},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(mock.Commands) != 0 {
+				t.Errorf("expected no commands, got %v", mock.Commands)
+			}
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got %+v", tt.wantSuccess, resp)
+			}
+
+			checks, _ := resp.Outputs["checks"].([]approvalCheck)
+			var failed []string
+			for _, c := range checks {
+				if !c.Passed {
+					failed = append(failed, c.Check+" "+c.Subject)
+				}
+			}
+			if strings.Join(failed, "|") != strings.Join(tt.wantFailed, "|") {
+				t.Errorf("expected failed checks %q, got %+v", tt.wantFailed, checks)
+			}
+			for _, f := range tt.wantFailed {
+				if !strings.Contains(resp.Error, f) {
+					t.Errorf("expected error to report %q, got %q", f, resp.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutePreApproveDryRunSkipsExecKeySource(t *testing.T) {
+	dir := chdirTemp(t)
+	buildTestPackage(t, dir, "mypackage.2.0.0.nupkg", testNuspec, "2.0.0")
+	server := newTestFeed(t)
+	defer server.Close()
+
+	config := map[string]any{
+		"package_path":   "mypackage.{{version}}.nupkg",
+		"api_key_source": `exec:op read "op://ci/My Item/credential"`,
+		"source":         "http://localhost:8080/",
+		"feed_url":       server.URL + "/api/v2",
+	}
+	for _, dryRun := range []bool{true, false} {
+		keyExec := &MockCommandExecutor{Output: []byte("exec-secret")}
+		p := &ChocolateyPlugin{keyExecutor: keyExec, httpClient: server.Client()}
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPreApprove,
+			Config:  config,
+			Context: plugin.ReleaseContext{Version: "v2.0.0"},
+			DryRun:  dryRun,
+		})
+		if err != nil || !resp.Success {
+			t.Fatalf("dry run %v: expected success, got %+v %v", dryRun, resp, err)
+		}
+
+		wantRuns := 1
+		if dryRun {
+			wantRuns = 0
+		}
+		if len(keyExec.Commands) != wantRuns {
+			t.Errorf("dry run %v: expected %d key command(s), got %v", dryRun, wantRuns, keyExec.Commands)
+		}
+	}
+}
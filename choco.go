@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+const (
+	// defaultChocoPath is the choco executable looked up on PATH.
+	defaultChocoPath = "choco"
+	// defaultMinChocoVersion is the oldest choco accepted by default.
+	defaultMinChocoVersion = "1.0.0"
+	// chocoVersionTimeout bounds `choco --version`.
+	chocoVersionTimeout = 30 * time.Second
+)
+
+// chocoVersionPattern matches the version line printed by `choco --version`.
+// Chocolatey may print warnings on other lines.
+var chocoVersionPattern = regexp.MustCompile(`(?m)^\s*v?(\d+(?:\.\d+){1,3})(?:-[0-9A-Za-z.-]+)?\s*$`)
+
+// errChocoNotFound is returned when the choco executable does not exist.
+var errChocoNotFound = errors.New("choco not found")
+
+// minVersionPattern matches min_choco_version.
+var minVersionPattern = regexp.MustCompile(`^\d+(\.\d+){0,3}$`)
+
+// envNamePattern matches environment variable names accepted in env.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// extraArgFlags are the choco push options allowed in extra_args that take
+// no value.
+var extraArgFlags = map[string]bool{
+	"-d":                          true,
+	"--debug":                     true,
+	"-v":                          true,
+	"--verbose":                   true,
+	"--trace":                     true,
+	"--no-color":                  true,
+	"--nocolor":                   true,
+	"-r":                          true,
+	"--limit-output":              true,
+	"--limitoutput":               true,
+	"--skip-compatibility-checks": true,
+	"--ignore-http-cache":         true,
+	"--proxy-bypass-on-local":     true,
+}
+
+// extraArgValueOptions are the choco push options allowed in extra_args that
+// take a value, either as --option=value or as the next argument.
+var extraArgValueOptions = map[string]bool{
+	"--execution-timeout": true,
+	"--log-file":          true,
+	"--proxy":             true,
+	"--proxy-user":        true,
+	"--proxy-password":    true,
+	"--proxy-bypass-list": true,
+}
+
+// validateExtraArgs checks extra_args against the allowlist. Options that
+// choose the source, the API key or --force are set by the plugin and cannot
+// be overridden.
+func validateExtraArgs(args []string) error {
+	for i := 0; i < len(args); i++ {
+		name, _, hasValue := strings.Cut(args[i], "=")
+		switch {
+		case extraArgFlags[name] && !hasValue:
+		case extraArgValueOptions[name]:
+			if !hasValue {
+				if i+1 >= len(args) || strings.HasPrefix(args[i+1], "-") {
+					return fmt.Errorf("extra_args option %s requires a value", name)
+				}
+				i++
+			}
+		default:
+			return fmt.Errorf("extra_args option %q is not allowed", args[i])
+		}
+	}
+	return nil
+}
+
+// validateChocoEnv checks the names of the env variables.
+func validateChocoEnv(env map[string]string) error {
+	for _, pair := range chocoEnv(env) {
+		name, _, _ := strings.Cut(pair, "=")
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+	}
+	return nil
+}
+
+// extraArgValues returns the values given for option in extra_args.
+func extraArgValues(args []string, option string) []string {
+	var values []string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != option {
+			continue
+		}
+		if hasValue {
+			values = append(values, value)
+		} else if i+1 < len(args) {
+			values = append(values, args[i+1])
+			i++
+		}
+	}
+	return values
+}
+
+// chocoEnv returns env as NAME=value pairs, sorted by name.
+func chocoEnv(env map[string]string) []string {
+	pairs := make([]string, 0, len(env))
+	for name, value := range env {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// runChoco runs choco with cfg's choco_path and, when the executor supports
+// it, the variables from env.
+func (p *ChocolateyPlugin) runChoco(ctx context.Context, cfg *Config, args ...string) ([]byte, error) {
+	return p.runChocoEnv(ctx, cfg, nil, args...)
+}
+
+// runChocoEnv is runChoco with extra environment variables added after env.
+func (p *ChocolateyPlugin) runChocoEnv(ctx context.Context, cfg *Config, extraEnv []string, args ...string) ([]byte, error) {
+	return p.runChocoStream(ctx, cfg, extraEnv, nil, args...)
+}
+
+// runChocoStream is runChocoEnv passing each line of output to onLine while
+// choco runs, when set and supported by the executor.
+func (p *ChocolateyPlugin) runChocoStream(ctx context.Context, cfg *Config, extraEnv []string, onLine func(line string), args ...string) ([]byte, error) {
+	executor := p.chocoExecutor(cfg)
+	env := append(chocoEnv(cfg.Env), extraEnv...)
+	if streamExecutor, ok := executor.(StreamCommandExecutor); ok && onLine != nil {
+		return streamExecutor.RunStream(ctx, env, onLine, cfg.ChocoPath, args...)
+	}
+	if envExecutor, ok := executor.(EnvCommandExecutor); ok && len(env) > 0 {
+		return envExecutor.RunEnv(ctx, env, cfg.ChocoPath, args...)
+	}
+	return executor.Run(ctx, cfg.ChocoPath, args...)
+}
+
+// chocoVersion runs `choco --version` and returns the version it reports.
+func (p *ChocolateyPlugin) chocoVersion(ctx context.Context, cfg *Config) (string, error) {
+	chocoPath := cfg.ChocoPath
+	execCtx, cancel := context.WithTimeout(ctx, chocoVersionTimeout)
+	defer cancel()
+
+	output, err := p.runChoco(execCtx, cfg, "--version")
+	if cfg.Executor == ExecutorDocker && (errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist)) {
+		return "", fmt.Errorf("%w: container runtime %q does not exist or is not on PATH; install it, set container_runtime to its location, or use executor: local", errChocoNotFound, cfg.ContainerRuntime)
+	}
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: %q does not exist or is not on PATH; install Chocolatey (https://chocolatey.org/install), set choco_path to its location, or use push_method: native", errChocoNotFound, chocoPath)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s --version failed: %v\nOutput: %s", chocoPath, err, string(output))
+	}
+
+	m := chocoVersionPattern.FindStringSubmatch(string(output))
+	if m == nil {
+		return "", fmt.Errorf("cannot read the choco version from %s --version output: %s", chocoPath, strings.TrimSpace(string(output)))
+	}
+	return m[1], nil
+}
+
+// compareVersions compares dotted numeric versions, treating missing
+// segments as zero. It returns -1, 0 or 1.
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// chocoPreflight checks that choco is installed and at least
+// min_choco_version, so a missing toolchain fails the release before anything
+// is published.
+func (p *ChocolateyPlugin) chocoPreflight(ctx context.Context, cfg *Config) *plugin.ExecuteResponse {
+	version, err := p.chocoVersion(ctx, cfg)
+	if err != nil {
+		resp := &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("choco preflight failed: %v", err),
+			Outputs: map[string]any{"choco_path": cfg.ChocoPath},
+		}
+		if errors.Is(err, errChocoNotFound) {
+			setErrorCode(resp.Outputs, ErrorCodeChocoNotInstalled)
+		}
+		return resp
+	}
+
+	outputs := map[string]any{
+		"choco_path":    cfg.ChocoPath,
+		"choco_version": version,
+	}
+	if compareVersions(version, cfg.MinChocoVersion) < 0 {
+		setErrorCode(outputs, ErrorCodeChocoOutdated)
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("choco preflight failed: choco %s is older than the minimum supported version %s", version, cfg.MinChocoVersion),
+			Outputs: outputs,
+		}
+	}
+
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found choco %s", version),
+		Outputs: outputs,
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "2.2.2", b: "1.0.0", expected: 1},
+		{a: "1.0", b: "1.0.0", expected: 0},
+		{a: "0.12.1", b: "1.0.0", expected: -1},
+		{a: "1.10.0", b: "1.9.0", expected: 1},
+		{a: "2.0.0.1", b: "2.0.0", expected: 1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.expected {
+			t.Errorf("compareVersions(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestExecuteChocoPreflight(t *testing.T) {
+	tests := []struct {
+		name         string
+		chocoPath    string
+		config       map[string]any
+		output       string
+		err          error
+		wantSuccess  bool
+		wantVersion  string
+		wantError    string
+		wantCode     string
+		wantCommands int
+	}{
+		{
+			name:         "installed",
+			chocoPath:    `C:\ProgramData\chocolatey\bin\choco.exe`,
+			output:       "2.2.2\n",
+			wantSuccess:  true,
+			wantVersion:  "2.2.2",
+			wantCommands: 1,
+		},
+		{
+			name:         "version after warnings",
+			output:       "Chocolatey detected you are not running from an elevated command shell\n1.4.0\n",
+			wantSuccess:  true,
+			wantVersion:  "1.4.0",
+			wantCommands: 1,
+		},
+		{
+			name:         "not installed",
+			err:          &exec.Error{Name: "choco", Err: exec.ErrNotFound},
+			wantError:    `choco preflight failed: choco not found: "choco" does not exist or is not on PATH`,
+			wantCode:     ErrorCodeChocoNotInstalled,
+			wantCommands: 1,
+		},
+		{
+			name:         "outdated",
+			output:       "0.12.1",
+			wantError:    "choco preflight failed: choco 0.12.1 is older than the minimum supported version 1.0.0",
+			wantCode:     ErrorCodeChocoOutdated,
+			wantVersion:  "0.12.1",
+			wantCommands: 1,
+		},
+		{
+			name:         "custom minimum",
+			config:       map[string]any{"min_choco_version": "2.3"},
+			output:       "2.2.2",
+			wantError:    "choco 2.2.2 is older than the minimum supported version 2.3",
+			wantCode:     ErrorCodeChocoOutdated,
+			wantVersion:  "2.2.2",
+			wantCommands: 1,
+		},
+		{
+			name:         "unreadable version",
+			output:       "Chocolatey v?",
+			wantError:    "cannot read the choco version",
+			wantCommands: 1,
+		},
+		{
+			name:         "command failure",
+			output:       "boom",
+			err:          errors.New("exit status 1"),
+			wantError:    "choco preflight failed: choco --version failed: exit status 1",
+			wantCommands: 1,
+		},
+		{
+			name:        "native push skips preflight",
+			config:      map[string]any{"push_method": "native"},
+			err:         &exec.Error{Name: "choco", Err: exec.ErrNotFound},
+			wantSuccess: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockCommandExecutor{Output: []byte(tt.output), Err: tt.err}
+			p := &ChocolateyPlugin{cmdExecutor: mock}
+
+			config := map[string]any{"package_path": "mypackage.{{version}}.nupkg"}
+			wantName := "choco"
+			if tt.chocoPath != "" {
+				config["choco_path"] = tt.chocoPath
+				wantName = tt.chocoPath
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPrePublish,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+				DryRun:  true,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got %+v", tt.wantSuccess, resp)
+			}
+			if tt.wantError != "" && !strings.Contains(resp.Error, tt.wantError) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.wantError, resp.Error)
+			}
+			if tt.wantVersion != "" && resp.Outputs["choco_version"] != tt.wantVersion {
+				t.Errorf("expected choco_version %s, got %v", tt.wantVersion, resp.Outputs["choco_version"])
+			}
+			if tt.wantCode != "" && resp.Outputs["error_code"] != tt.wantCode {
+				t.Errorf("expected error_code %s, got %v", tt.wantCode, resp.Outputs["error_code"])
+			}
+			if len(mock.Commands) != tt.wantCommands {
+				t.Fatalf("expected %d commands, got %v", tt.wantCommands, mock.Commands)
+			}
+			if tt.wantCommands > 0 {
+				cmd := mock.Commands[0]
+				if cmd.Name != wantName || strings.Join(cmd.Args, " ") != "--version" {
+					t.Errorf("unexpected command: %+v", cmd)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteUsesChocoPath(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	mock := &MockCommandExecutor{Output: []byte("pushed")}
+	p := &ChocolateyPlugin{cmdExecutor: mock, logger: discardLogger}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"package_path": "mypackage.{{version}}.nupkg",
+			"api_key":      "test-api-key",
+			"source":       "http://localhost:8080/",
+			"choco_path":   "/opt/chocolatey/choco",
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+	for _, cmd := range mock.Commands {
+		if cmd.Name != "/opt/chocolatey/choco" {
+			t.Errorf("expected choco_path to be used, got %+v", cmd)
+		}
+	}
+}
+
+func TestValidateMinChocoVersion(t *testing.T) {
+	p := &ChocolateyPlugin{}
+	for version, valid := range map[string]bool{"1.0.0": true, "2": true, "1.4.0.1": true, "v1.0": false, "latest": false} {
+		resp, err := p.Validate(context.Background(), map[string]any{
+			"package_path":      "mypackage.{{version}}.nupkg",
+			"min_choco_version": version,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Valid != valid {
+			t.Errorf("min_choco_version %q: expected valid=%v, got errors %v", version, valid, resp.Errors)
+		}
+	}
+}
+
+func TestValidateExtraArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantError string
+	}{
+		{name: "none"},
+		{name: "flags", args: []string{"--skip-compatibility-checks", "--debug", "-r"}},
+		{name: "value with equals", args: []string{"--execution-timeout=600"}},
+		{name: "value as next argument", args: []string{"--proxy", "http://proxy:8080", "--proxy-user", "ci"}},
+		{name: "api key", args: []string{"--api-key", "other"}, wantError: `extra_args option "--api-key" is not allowed`},
+		{name: "short key", args: []string{"-k=other"}, wantError: `extra_args option "-k=other" is not allowed`},
+		{name: "source", args: []string{"--source=https://evil.example/"}, wantError: "is not allowed"},
+		{name: "short source", args: []string{"-s", "https://evil.example/"}, wantError: "is not allowed"},
+		{name: "force", args: []string{"--force"}, wantError: "is not allowed"},
+		{name: "flag with value", args: []string{"--debug=false"}, wantError: "is not allowed"},
+		{name: "value hides option", args: []string{"--log-file", "--api-key"}, wantError: "extra_args option --log-file requires a value"},
+		{name: "missing value", args: []string{"--execution-timeout"}, wantError: "requires a value"},
+		{name: "positional", args: []string{"other.nupkg"}, wantError: "is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateExtraArgs(tt.args)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("expected error containing '%s', got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestValidateExtraArgsAndEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     map[string]any
+		wantErrFld string
+	}{
+		{name: "valid", config: map[string]any{
+			"extra_args": []any{"--skip-compatibility-checks"},
+			"env":        map[string]any{"ChocolateyToolsLocation": `C:\tools`},
+		}},
+		{name: "disallowed extra arg", config: map[string]any{"extra_args": []any{"--api-key=other"}}, wantErrFld: "extra_args"},
+		{name: "invalid env name", config: map[string]any{"env": map[string]any{"BAD-NAME": "x"}}, wantErrFld: "env"},
+	}
+
+	p := &ChocolateyPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{"package_path": "mypackage.{{version}}.nupkg"}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+			resp, err := p.Validate(context.Background(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErrFld == "" {
+				if !resp.Valid {
+					t.Errorf("expected valid, got errors: %v", resp.Errors)
+				}
+				return
+			}
+			if resp.Valid || len(resp.Errors) != 1 || resp.Errors[0].Field != tt.wantErrFld {
+				t.Errorf("expected single error on '%s', got %v", tt.wantErrFld, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestExecuteExtraArgsAndEnv(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	mock := &MockCommandExecutor{Output: []byte("proxy secret-pass rejected"), Err: errors.New("exit status 1"), ErrOn: "push"}
+	p := &ChocolateyPlugin{cmdExecutor: mock, logger: discardLogger}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"package_path": "mypackage.{{version}}.nupkg",
+			"api_key":      "test-api-key",
+			"source":       "http://localhost:8080/",
+			"extra_args":   []any{"--skip-compatibility-checks", "--proxy-password", "secret-pass"},
+			"env":          map[string]any{"ChocolateyToolsLocation": "/opt/tools", "CI": "true"},
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success {
+		t.Fatal("expected push failure")
+	}
+	if strings.Contains(resp.Error, "secret-pass") {
+		t.Errorf("expected proxy password to be redacted, got %s", resp.Error)
+	}
+
+	wantEnv := "CI=true ChocolateyToolsLocation=/opt/tools"
+	for _, cmd := range mock.Commands {
+		if strings.Join(cmd.Env, " ") != wantEnv {
+			t.Errorf("expected env %q, got %+v", wantEnv, cmd)
+		}
+	}
+	pushes := pushCommands(mock.Commands)
+	if len(pushes) != 1 {
+		t.Fatalf("expected 1 push, got %v", mock.Commands)
+	}
+	want := "push mypackage.1.0.0.nupkg --api-key test-api-key --source http://localhost:8080/ --timeout 300 --skip-compatibility-checks --proxy-password secret-pass"
+	if got := strings.Join(pushes[0].Args, " "); got != want {
+		t.Errorf("expected args %q, got %q", want, got)
+	}
+}
+
+func TestExecuteRechecksExtraArgsAndEnv(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	tests := []struct {
+		name      string
+		config    map[string]any
+		wantError string
+	}{
+		{name: "disallowed extra arg", config: map[string]any{"extra_args": []any{"--source=http://evil.example/"}}, wantError: "invalid extra_args"},
+		{name: "invalid env name", config: map[string]any{"env": map[string]any{"BAD-NAME": "x"}}, wantError: "invalid env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{
+				"package_path": "mypackage.{{version}}.nupkg",
+				"api_key":      "test-api-key",
+				"source":       "http://localhost:8080/",
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+
+			// Execute does not rely on Validate having run.
+			mock := &MockCommandExecutor{}
+			p := &ChocolateyPlugin{cmdExecutor: mock}
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success || !strings.Contains(resp.Error, tt.wantError) {
+				t.Errorf("expected error containing %q, got %+v", tt.wantError, resp)
+			}
+			if len(mock.Commands) != 0 {
+				t.Errorf("expected no choco commands, got %v", mock.Commands)
+			}
+		})
+	}
+}
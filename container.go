@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Executors selecting where choco runs.
+const (
+	// ExecutorLocal runs the choco executable on the release runner.
+	ExecutorLocal = "local"
+	// ExecutorDocker runs choco inside a container with DockerCommandExecutor.
+	ExecutorDocker = "docker"
+)
+
+const (
+	// defaultContainerRuntime is the container CLI used by executor: docker.
+	defaultContainerRuntime = "docker"
+	// defaultContainerImage provides choco on Linux.
+	defaultContainerImage = "chocolatey/choco:latest"
+	// containerAPIKeyEnv carries the API key into the container; see containerScript.
+	containerAPIKeyEnv = "CHOCOLATEY_API_KEY"
+	// containerPackageDir is where package directories are mounted.
+	containerPackageDir = "/packages"
+	// containerRemoveTimeout bounds removing a cancelled container.
+	containerRemoveTimeout = 30 * time.Second
+)
+
+// containerScript runs the command given as its arguments, appending
+// --api-key when containerAPIKeyEnv is set so the key stays off the runtime's
+// command line. choco reads keys from no other channel, and registering one
+// in the container's config with `choco apikey add` takes it as an argument
+// too, so the key is still in the argv of choco inside the container, which
+// the host's process list shows.
+const containerScript = `if [ -n "$` + containerAPIKeyEnv + `" ]; then exec "$@" --api-key "$` + containerAPIKeyEnv + `"; fi; exec "$@"`
+
+// DockerCommandExecutor runs commands in a throwaway container with
+// `docker run` or `podman run`. The directory of every .nupkg argument is
+// bind-mounted read-only and the argument rewritten to its path inside the
+// container. Environment variables are passed by name, so their values stay
+// off the runtime's command line.
+type DockerCommandExecutor struct {
+	// Runtime is the container CLI, such as docker or podman.
+	Runtime string
+	// Image is the image providing the command.
+	Image string
+}
+
+// Run executes a command in a container and returns the tail of its
+// combined output.
+func (e *DockerCommandExecutor) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return e.RunStream(ctx, nil, nil, name, args...)
+}
+
+// RunEnv executes a command in a container with env set and returns the tail
+// of its combined output.
+func (e *DockerCommandExecutor) RunEnv(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	return e.RunStream(ctx, env, nil, name, args...)
+}
+
+// RunStream executes a command in a container with env set, passing each
+// line of combined output to onLine, and returns its tail. When ctx is done
+// the container is removed, not just the runtime client.
+func (e *DockerCommandExecutor) RunStream(ctx context.Context, env []string, onLine func(line string), name string, args ...string) ([]byte, error) {
+	containerName := fmt.Sprintf("relicta-choco-%016x", rand.Uint64())
+	runArgs, err := e.runArgs(containerName, env, name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, e.Runtime, runArgs...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Cancel = func() error {
+		rmCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), containerRemoveTimeout)
+		defer cancel()
+		_ = exec.CommandContext(rmCtx, e.Runtime, "rm", "--force", containerName).Run()
+		return cmd.Process.Kill()
+	}
+	cmd.WaitDelay = containerRemoveTimeout
+	return runStreaming(cmd, onLine)
+}
+
+// runArgs builds the `run` arguments for the runtime.
+func (e *DockerCommandExecutor) runArgs(containerName string, env []string, name string, args []string) ([]string, error) {
+	runArgs := []string{"run", "--rm", "--name", containerName, "--entrypoint", "/bin/sh"}
+	for _, pair := range env {
+		envName, _, _ := strings.Cut(pair, "=")
+		runArgs = append(runArgs, "--env", envName)
+	}
+
+	mounts := make(map[string]string)
+	cmdArgs := make([]string, len(args))
+	for i, arg := range args {
+		cmdArgs[i] = arg
+		if !strings.EqualFold(filepath.Ext(arg), ".nupkg") {
+			continue
+		}
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve package path %s: %w", arg, err)
+		}
+		dir := filepath.Dir(abs)
+		target, ok := mounts[dir]
+		if !ok {
+			target = path.Join(containerPackageDir, strconv.Itoa(len(mounts)))
+			mounts[dir] = target
+			runArgs = append(runArgs, "--mount", fmt.Sprintf("type=bind,source=%s,target=%s,readonly", dir, target))
+		}
+		cmdArgs[i] = path.Join(target, filepath.Base(abs))
+	}
+
+	runArgs = append(runArgs, e.Image, "-c", containerScript, "sh", name)
+	return append(runArgs, cmdArgs...), nil
+}
+
+// chocoExecutor returns the executor for choco commands: the injected one,
+// a DockerCommandExecutor for executor: docker, or RealCommandExecutor.
+func (p *ChocolateyPlugin) chocoExecutor(cfg *Config) CommandExecutor {
+	if p.cmdExecutor == nil && cfg.Executor == ExecutorDocker {
+		return &DockerCommandExecutor{Runtime: cfg.ContainerRuntime, Image: cfg.ContainerImage}
+	}
+	return p.getExecutor()
+}
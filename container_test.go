@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestDockerRunArgs(t *testing.T) {
+	dir := chdirTemp(t)
+	other := t.TempDir()
+
+	e := &DockerCommandExecutor{Runtime: "podman", Image: "chocolatey/choco:v2.2.2"}
+	args, err := e.runArgs("relicta-choco-test", []string{"CHOCOLATEY_API_KEY=secret", "CI=true"}, "choco", []string{
+		"push", "mypackage.1.0.0.nupkg", "--source", "https://push.chocolatey.org/",
+		filepath.Join(dir, "other.1.0.0.nupkg"), filepath.Join(other, "third.1.0.0.nupkg"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"run", "--rm", "--name", "relicta-choco-test", "--entrypoint", "/bin/sh",
+		"--env", "CHOCOLATEY_API_KEY", "--env", "CI",
+		"--mount", "type=bind,source=" + dir + ",target=/packages/0,readonly",
+		"--mount", "type=bind,source=" + other + ",target=/packages/1,readonly",
+		"chocolatey/choco:v2.2.2", "-c", containerScript, "sh", "choco",
+		"push", "/packages/0/mypackage.1.0.0.nupkg", "--source", "https://push.chocolatey.org/",
+		"/packages/0/other.1.0.0.nupkg", "/packages/1/third.1.0.0.nupkg",
+	}
+	if strings.Join(args, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected run args:\n got %q\nwant %q", args, want)
+	}
+	if strings.Contains(strings.Join(args, " "), "secret") {
+		t.Error("expected env values to stay off the command line")
+	}
+}
+
+func TestDockerCommandExecutorCancel(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "runtime.log")
+	runtime := filepath.Join(dir, "runtime")
+	script := "#!/bin/sh\necho \"$1 $2 $3 $4\" >> " + log + "\nif [ \"$1\" = run ]; then exec sleep 10; fi\n"
+	if err := os.WriteFile(runtime, []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write runtime: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := (&DockerCommandExecutor{Runtime: runtime, Image: "choco"}).Run(ctx, "choco", "--version")
+	if err == nil {
+		t.Fatal("expected error from cancelled run")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected run to stop at the timeout, took %s", elapsed)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatalf("failed to read runtime log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "run --rm --name relicta-choco-") {
+		t.Fatalf("unexpected runtime calls: %q", lines)
+	}
+	name := strings.Fields(lines[0])[3]
+	if strings.TrimSpace(lines[1]) != "rm --force "+name {
+		t.Errorf("expected container %s to be removed, got %q", name, lines[1])
+	}
+}
+
+func TestExecuteDockerExecutor(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	mock := &MockCommandExecutor{Output: []byte("pushed")}
+	p := &ChocolateyPlugin{cmdExecutor: mock, logger: discardLogger}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"package_path": "mypackage.{{version}}.nupkg",
+			"api_key":      "test-api-key",
+			"source":       "http://localhost:8080/",
+			"executor":     "docker",
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+
+	// No registration: the key reaches the container through the environment.
+	if len(mock.Commands) != 1 {
+		t.Fatalf("expected only the push command, got %+v", mock.Commands)
+	}
+	cmd := mock.Commands[0]
+	if cmd.Args[0] != "push" || strings.Join(cmd.Env, " ") != "CHOCOLATEY_API_KEY=test-api-key" {
+		t.Errorf("unexpected push command: %+v", cmd)
+	}
+	for _, arg := range cmd.Args {
+		if arg == "test-api-key" {
+			t.Errorf("expected API key to stay off the command line, got %v", cmd.Args)
+		}
+	}
+}
+
+func TestValidateExecutor(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     map[string]any
+		wantErrFld string
+	}{
+		{name: "local", config: map[string]any{"executor": "local"}},
+		{name: "docker", config: map[string]any{"executor": "docker", "container_runtime": "podman"}},
+		{name: "unknown", config: map[string]any{"executor": "ssh"}, wantErrFld: "executor"},
+		{name: "docker with native push", config: map[string]any{"executor": "docker", "push_method": "native"}, wantErrFld: "executor"},
+	}
+
+	p := &ChocolateyPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{"package_path": "mypackage.{{version}}.nupkg"}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+			resp, err := p.Validate(context.Background(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErrFld == "" {
+				if !resp.Valid {
+					t.Errorf("expected valid, got errors: %v", resp.Errors)
+				}
+				return
+			}
+			if resp.Valid || len(resp.Errors) != 1 || resp.Errors[0].Field != tt.wantErrFld {
+				t.Errorf("expected single error on '%s', got %v", tt.wantErrFld, resp.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Error codes reported in the "error_code" output when a push fails.
+const (
+	// ErrorCodeUnauthorized is a missing or invalid API key (401).
+	ErrorCodeUnauthorized = "unauthorized"
+	// ErrorCodeForbidden is a key without permission to push the package (403).
+	ErrorCodeForbidden = "forbidden"
+	// ErrorCodeConflict is a version that is already published (409).
+	ErrorCodeConflict = "conflict"
+	// ErrorCodePackageTooLarge is a package over the feed's size limit (413).
+	ErrorCodePackageTooLarge = "package_too_large"
+	// ErrorCodeValidationRejected is a package the feed refused as invalid (400, 422).
+	ErrorCodeValidationRejected = "validation_rejected"
+	// ErrorCodeClient is any other 4xx response from the feed.
+	ErrorCodeClient = "client_error"
+	// ErrorCodeServer is a 5xx response from the feed.
+	ErrorCodeServer = "server_error"
+	// ErrorCodeNetwork is a connection that was reset, refused or could not be made.
+	ErrorCodeNetwork = "network"
+	// ErrorCodeTimeout is a push that exceeded its timeout.
+	ErrorCodeTimeout = "timeout"
+	// ErrorCodeChocoNotInstalled is a missing choco executable.
+	ErrorCodeChocoNotInstalled = "choco_not_installed"
+	// ErrorCodeChocoOutdated is a choco older than min_choco_version.
+	ErrorCodeChocoOutdated = "choco_outdated"
+	// ErrorCodeUnknown is a failure that could not be classified.
+	ErrorCodeUnknown = "unknown"
+)
+
+// remediations holds a hint for resolving each error code.
+var remediations = map[string]string{
+	ErrorCodeUnauthorized:       "Check that the API key is set and valid for the source; community repository keys are shown on the account page of community.chocolatey.org.",
+	ErrorCodeForbidden:          "The API key is not allowed to push this package; make sure its account owns or maintains the package id.",
+	ErrorCodeConflict:           "This version is already published; bump the version, use package_fix_version for a package fix, or set on_exists to skip.",
+	ErrorCodePackageTooLarge:    "The package exceeds the feed's size limit; download large installers at install time instead of embedding them.",
+	ErrorCodeValidationRejected: "The feed rejected the package as invalid; enable inspect and validate_nuspec to find the problem before pushing.",
+	ErrorCodeClient:             "The feed rejected the request; check the source URL and the output for details.",
+	ErrorCodeServer:             "The feed had a server error; try again later or set retries to retry automatically.",
+	ErrorCodeNetwork:            "Could not reach the feed; check the source URL, DNS, proxy and firewall settings, or set retries.",
+	ErrorCodeTimeout:            "The push timed out; increase timeout or set retries.",
+	ErrorCodeChocoNotInstalled:  "choco was not found; install Chocolatey, set choco_path to its location, or set push_method to native.",
+	ErrorCodeChocoOutdated:      "Upgrade Chocolatey with `choco upgrade chocolatey`, or use push_method: native.",
+	ErrorCodeUnknown:            "See the output for details.",
+}
+
+// remediation returns the hint for an error code.
+func remediation(code string) string {
+	return remediations[code]
+}
+
+// setErrorCode adds the error_code and remediation outputs for a failure
+// classified with code.
+func setErrorCode(outputs map[string]any, code string) {
+	if code == "" {
+		return
+	}
+	outputs["error_code"] = code
+	outputs["remediation"] = remediation(code)
+}
+
+// chocoStatusPattern finds HTTP status codes in the NuGet and .NET error
+// messages choco prints, such as "The remote server returned an error: (502)
+// Bad Gateway.", "Response status code does not indicate success: 503
+// (Service Unavailable)." or "Failed to process request. '409 Conflict'".
+// Other numbers, such as package sizes or counts, are not status codes.
+var chocoStatusPattern = regexp.MustCompile(`(?i)(?:remote server returned an error:\s*\(|status code does not indicate success:\s*|failed to process request\.\s*')([45]\d{2})\b`)
+
+// classifyPushError assigns an error code to a failed push from its error
+// and output.
+func classifyPushError(err error, output string) string {
+	var pushErr *PushError
+	if errors.As(err, &pushErr) {
+		return classifyStatus(pushErr.StatusCode)
+	}
+
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return ErrorCodeChocoNotInstalled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorCodeTimeout
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF), errors.As(err, &dnsErr):
+		return ErrorCodeNetwork
+	}
+
+	if m := chocoStatusPattern.FindStringSubmatch(output); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		return classifyStatus(code)
+	}
+
+	lower := strings.ToLower(output)
+	switch {
+	case strings.Contains(lower, "already exists"):
+		return ErrorCodeConflict
+	case strings.Contains(lower, "unauthorized"):
+		return ErrorCodeUnauthorized
+	case strings.Contains(lower, "forbidden"):
+		return ErrorCodeForbidden
+	case strings.Contains(lower, "too large"), strings.Contains(lower, "exceeds the maximum"):
+		return ErrorCodePackageTooLarge
+	case strings.Contains(lower, "timed out"):
+		return ErrorCodeTimeout
+	case strings.Contains(lower, "connection reset"), strings.Contains(lower, "connection was closed"),
+		strings.Contains(lower, "connection refused"), strings.Contains(lower, "actively refused"),
+		strings.Contains(lower, "could not be resolved"), strings.Contains(lower, "unable to connect"):
+		return ErrorCodeNetwork
+	case strings.Contains(lower, "is not recognized as"), strings.Contains(lower, "command not found"):
+		return ErrorCodeChocoNotInstalled
+	}
+	return ErrorCodeUnknown
+}
+
+// classifyStatus assigns an error code to an HTTP status code.
+func classifyStatus(code int) string {
+	switch {
+	case code == 401:
+		return ErrorCodeUnauthorized
+	case code == 403:
+		return ErrorCodeForbidden
+	case code == 409:
+		return ErrorCodeConflict
+	case code == 413:
+		return ErrorCodePackageTooLarge
+	case code == 400 || code == 422:
+		return ErrorCodeValidationRejected
+	case code == 408:
+		return ErrorCodeTimeout
+	case code >= 500:
+		return ErrorCodeServer
+	case code >= 400:
+		return ErrorCodeClient
+	}
+	return ErrorCodeUnknown
+}
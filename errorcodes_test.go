@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestClassifyPushError(t *testing.T) {
+	exitErr := errors.New("exit status 1")
+
+	tests := []struct {
+		name     string
+		err      error
+		output   string
+		expected string
+	}{
+		{name: "native 401", err: &PushError{StatusCode: 401, Status: "401 Unauthorized"}, expected: ErrorCodeUnauthorized},
+		{name: "native 403", err: &PushError{StatusCode: 403, Status: "403 Forbidden"}, expected: ErrorCodeForbidden},
+		{name: "native 409", err: &PushError{StatusCode: 409, Status: "409 Conflict"}, expected: ErrorCodeConflict},
+		{name: "native 413", err: &PushError{StatusCode: 413, Status: "413 Request Entity Too Large"}, expected: ErrorCodePackageTooLarge},
+		{name: "native 400", err: &PushError{StatusCode: 400, Status: "400 Bad Request"}, expected: ErrorCodeValidationRejected},
+		{name: "native 404", err: &PushError{StatusCode: 404, Status: "404 Not Found"}, expected: ErrorCodeClient},
+		{name: "native 502", err: &PushError{StatusCode: 502, Status: "502 Bad Gateway"}, expected: ErrorCodeServer},
+		{name: "deadline", err: fmt.Errorf("%w: signal: killed", context.DeadlineExceeded), expected: ErrorCodeTimeout},
+		{name: "connection reset", err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, expected: ErrorCodeNetwork},
+		{name: "dns failure", err: &net.DNSError{Err: "no such host", Name: "feed.example.com"}, expected: ErrorCodeNetwork},
+		{name: "choco missing", err: &exec.Error{Name: "choco", Err: exec.ErrNotFound}, expected: ErrorCodeChocoNotInstalled},
+		{name: "choco remote error", err: exitErr, output: "The remote server returned an error: (502) Bad Gateway.", expected: ErrorCodeServer},
+		{name: "choco unauthorized", err: exitErr, output: "Response status code does not indicate success: 401 (Unauthorized).", expected: ErrorCodeUnauthorized},
+		{name: "choco forbidden", err: exitErr, output: "Response status code does not indicate success: 403 (Forbidden).", expected: ErrorCodeForbidden},
+		{name: "choco conflict", err: exitErr, output: "Failed to process request. '409 Conflict'", expected: ErrorCodeConflict},
+		{name: "choco already exists", err: exitErr, output: "Error: Package already exists", expected: ErrorCodeConflict},
+		{name: "choco too large", err: exitErr, output: "The remote server returned an error: (413) Request Entity Too Large.", expected: ErrorCodePackageTooLarge},
+		{name: "choco validation", err: exitErr, output: "Response status code does not indicate success: 400 (The package is invalid).", expected: ErrorCodeValidationRejected},
+		{name: "choco timed out", err: exitErr, output: "The operation has timed out", expected: ErrorCodeTimeout},
+		{name: "choco connection reset", err: exitErr, output: "An existing connection was forcibly closed: connection reset", expected: ErrorCodeNetwork},
+		{name: "choco unresolved host", err: exitErr, output: "The remote name could not be resolved: 'feed.example.com'", expected: ErrorCodeNetwork},
+		{name: "version numbers ignored", err: exitErr, output: "Pushing mypackage 500.1.0 failed", expected: ErrorCodeUnknown},
+		{name: "package size ignored", err: exitErr, output: "Package size 413 KB", expected: ErrorCodeUnknown},
+		{name: "upload size ignored", err: exitErr, output: "Uploading package (412 MB)", expected: ErrorCodeUnknown},
+		{name: "package count ignored", err: exitErr, output: "Found 500 Packages", expected: ErrorCodeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyPushError(tt.err, tt.output); got != tt.expected {
+				t.Errorf("classifyPushError() = %s, want %s", got, tt.expected)
+			}
+			if remediation(tt.expected) == "" {
+				t.Errorf("expected a remediation hint for %s", tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecuteErrorCode(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	tests := []struct {
+		name     string
+		exec     *MockCommandExecutor
+		config   map[string]any
+		expected string
+	}{
+		{
+			name:     "duplicate version",
+			exec:     &MockCommandExecutor{Output: []byte("Response status code does not indicate success: 409 (Conflict)."), Err: errors.New("exit status 1"), ErrOn: "push"},
+			expected: ErrorCodeConflict,
+		},
+		{
+			name:     "bad key",
+			exec:     &MockCommandExecutor{Output: []byte("Response status code does not indicate success: 401 (Unauthorized)."), Err: errors.New("exit status 1"), ErrOn: "push"},
+			expected: ErrorCodeUnauthorized,
+		},
+		{
+			name:     "choco not installed",
+			exec:     &MockCommandExecutor{Err: &exec.Error{Name: "choco", Err: exec.ErrNotFound}},
+			expected: ErrorCodeChocoNotInstalled,
+		},
+		{
+			name: "source failure",
+			exec: &MockCommandExecutor{Output: []byte("Response status code does not indicate success: 403 (Forbidden)."), Err: errors.New("exit status 1"), ErrOn: "push"},
+			config: map[string]any{"sources": []any{
+				map[string]any{"url": "http://localhost:8080/", "api_key": "key-a"},
+			}},
+			expected: ErrorCodeForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ChocolateyPlugin{cmdExecutor: tt.exec, logger: discardLogger}
+			config := map[string]any{
+				"package_path": "mypackage.{{version}}.nupkg",
+				"api_key":      "test-api-key",
+				"source":       "http://localhost:8080/",
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success {
+				t.Fatal("expected failure")
+			}
+			if resp.Outputs["error_code"] != tt.expected || resp.Outputs["remediation"] != remediation(tt.expected) {
+				t.Errorf("expected error_code %s with remediation, got %v / %v", tt.expected, resp.Outputs["error_code"], resp.Outputs["remediation"])
+			}
+		})
+	}
+}
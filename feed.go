@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// On-exists modes controlling what happens when the version is already on the feed.
+const (
+	// OnExistsFail pushes unconditionally and lets the feed reject duplicates.
+	OnExistsFail = "fail"
+	// OnExistsSkip succeeds without pushing when the version already exists.
+	OnExistsSkip = "skip"
+	// OnExistsForce pushes with --force, overwriting where the feed allows it.
+	OnExistsForce = "force"
+)
+
+// Push outcomes reported in the "outcome" output.
+const (
+	OutcomePushed      = "pushed"
+	OutcomeSkipped     = "skipped"
+	OutcomeOverwritten = "overwritten"
+)
+
+// communityPushHost is the push-only host of the community repository, whose
+// packages are queried through communityFeedURL.
+const (
+	communityPushHost = "push.chocolatey.org"
+	communityFeedURL  = "https://community.chocolatey.org/api/v2/"
+)
+
+// feedURL returns the feed queried for existing packages. It defaults to the
+// /api/v2 root of the push source, or the community gallery for push.chocolatey.org.
+func feedURL(cfg *Config) string {
+	if cfg.FeedURL != "" {
+		return cfg.FeedURL
+	}
+	if u, err := url.Parse(cfg.Source); err == nil && strings.EqualFold(u.Hostname(), communityPushHost) {
+		return communityFeedURL
+	}
+	return strings.TrimSuffix(packageEndpoint(cfg.Source), "/package")
+}
+
+// PackageExists reports whether id and version are published on a feed. Feeds
+// ending in index.json are queried through the NuGet v3 registration index,
+// all others through the OData v2 Packages(Id,Version) entity.
+func (c *NuGetClient) PackageExists(ctx context.Context, feed, id, version string) (bool, error) {
+	if isV3Feed(feed) {
+		return c.registrationExists(ctx, feed, id, version)
+	}
+	return c.resourceExists(ctx, odataEntityURL(feed, id, version))
+}
+
+// isV3Feed reports whether feed is a NuGet v3 service index.
+func isV3Feed(feed string) bool {
+	return strings.HasSuffix(strings.ToLower(feed), "/index.json")
+}
+
+// odataEntityURL returns the OData v2 URL of a single package version.
+func odataEntityURL(feed, id, version string) string {
+	quote := func(s string) string {
+		return strings.ReplaceAll(url.PathEscape(strings.ReplaceAll(s, "'", "''")), "%27", "'")
+	}
+	return fmt.Sprintf("%s/Packages(Id='%s',Version='%s')", strings.TrimRight(feed, "/"), quote(id), quote(version))
+}
+
+// registrationExists looks the version up in the v3 registration index.
+func (c *NuGetClient) registrationExists(ctx context.Context, index, id, version string) (bool, error) {
+	body, err := c.get(ctx, index)
+	if err != nil {
+		return false, err
+	}
+
+	var service struct {
+		Resources []struct {
+			ID   string `json:"@id"`
+			Type string `json:"@type"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(body, &service); err != nil {
+		return false, fmt.Errorf("invalid service index: %w", err)
+	}
+
+	for _, r := range service.Resources {
+		if strings.HasPrefix(r.Type, "RegistrationsBaseUrl") {
+			leaf := fmt.Sprintf("%s/%s/%s.json", strings.TrimRight(r.ID, "/"),
+				url.PathEscape(strings.ToLower(id)), url.PathEscape(strings.ToLower(version)))
+			return c.resourceExists(ctx, leaf)
+		}
+	}
+	return false, fmt.Errorf("service index has no RegistrationsBaseUrl resource")
+}
+
+// resourceExists reports whether a GET of rawURL succeeds (true) or returns 404 (false).
+func (c *NuGetClient) resourceExists(ctx context.Context, rawURL string) (bool, error) {
+	_, err := c.get(ctx, rawURL)
+	if err == nil {
+		return true, nil
+	}
+	if pe, ok := err.(*PushError); ok && pe.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+// get fetches rawURL and returns its body, or a *PushError for non-success statuses.
+func (c *NuGetClient) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &PushError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       string(body),
+		}
+	}
+	return body, nil
+}
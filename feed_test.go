@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestFeedURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		expected string
+	}{
+		{name: "community push host", cfg: Config{Source: "https://push.chocolatey.org/"}, expected: communityFeedURL},
+		{name: "feed root", cfg: Config{Source: "https://nexus.example.com/repository/choco/"}, expected: "https://nexus.example.com/repository/choco/api/v2"},
+		{name: "api root", cfg: Config{Source: "https://nexus.example.com/api/v2/"}, expected: "https://nexus.example.com/api/v2"},
+		{name: "explicit feed", cfg: Config{Source: "https://push.chocolatey.org/", FeedURL: "https://feed.example.com/v3/index.json"}, expected: "https://feed.example.com/v3/index.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := feedURL(&tt.cfg); got != tt.expected {
+				t.Errorf("feedURL() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestODataEntityURL(t *testing.T) {
+	got := odataEntityURL("https://feed.example.com/api/v2/", "my'pkg", "1.0.0")
+	if got != "https://feed.example.com/api/v2/Packages(Id='my''pkg',Version='1.0.0')" {
+		t.Errorf("unexpected entity URL: %s", got)
+	}
+}
+
+// newTestFeed serves the OData v2 and v3 registration endpoints for the given
+// published id/version pairs.
+func newTestFeed(t *testing.T, published ...string) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v3/index.json":
+			_, _ = fmt.Fprintf(w, `{"version":"3.0.0","resources":[{"@id":"%s/v3/registration/","@type":"RegistrationsBaseUrl/3.6.0"}]}`, server.URL)
+			return
+		case r.URL.Path == "/broken/Packages(Id='mypackage',Version='1.0.0')":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for _, p := range published {
+			id, version, _ := strings.Cut(p, "@")
+			if r.URL.Path == fmt.Sprintf("/api/v2/Packages(Id='%s',Version='%s')", id, version) ||
+				r.URL.Path == fmt.Sprintf("/v3/registration/%s/%s.json", id, version) {
+				_, _ = w.Write([]byte("{}"))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	return server
+}
+
+func TestNuGetClientPackageExists(t *testing.T) {
+	server := newTestFeed(t, "mypackage@1.0.0")
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		feed      string
+		version   string
+		expected  bool
+		errSubstr string
+	}{
+		{name: "odata exists", feed: server.URL + "/api/v2", version: "1.0.0", expected: true},
+		{name: "odata missing", feed: server.URL + "/api/v2/", version: "1.0.1", expected: false},
+		{name: "v3 exists", feed: server.URL + "/v3/index.json", version: "1.0.0", expected: true},
+		{name: "v3 missing", feed: server.URL + "/v3/index.json", version: "1.0.1", expected: false},
+		{name: "server error", feed: server.URL + "/broken", version: "1.0.0", errSubstr: "500"},
+	}
+
+	client := &NuGetClient{HTTPClient: server.Client()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exists, err := client.PackageExists(context.Background(), tt.feed, "mypackage", tt.version)
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Errorf("expected error containing '%s', got %v", tt.errSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exists != tt.expected {
+				t.Errorf("expected exists=%v, got %v", tt.expected, exists)
+			}
+		})
+	}
+}
+
+func TestExecuteOnExists(t *testing.T) {
+	server := newTestFeed(t, "mypackage@1.0.0")
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+	writeTestPackage(t, dir, "mypackage.2.0.0.nupkg", "nupkg-bytes")
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	tests := []struct {
+		name        string
+		onExists    string
+		feed        string
+		version     string
+		wantSuccess bool
+		wantOutcome string
+		wantPush    bool
+		wantForce   bool
+		wantError   string
+	}{
+		{name: "skip existing", onExists: "skip", version: "v1.0.0", wantSuccess: true, wantOutcome: OutcomeSkipped},
+		{name: "skip new", onExists: "skip", version: "v2.0.0", wantSuccess: true, wantOutcome: OutcomePushed, wantPush: true},
+		{name: "force existing", onExists: "force", version: "v1.0.0", wantSuccess: true, wantOutcome: OutcomeOverwritten, wantPush: true, wantForce: true},
+		{name: "force new", onExists: "force", version: "v2.0.0", wantSuccess: true, wantOutcome: OutcomePushed, wantPush: true, wantForce: true},
+		{name: "fail does not query", onExists: "fail", feed: "/broken", version: "v1.0.0", wantSuccess: true, wantOutcome: OutcomePushed, wantPush: true},
+		{name: "skip with unreachable feed fails", onExists: "skip", feed: "/broken", version: "v1.0.0", wantError: "existence check failed"},
+		{name: "force with unreachable feed pushes", onExists: "force", feed: "/broken", version: "v1.0.0", wantSuccess: true, wantOutcome: OutcomePushed, wantPush: true, wantForce: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := server.URL + "/api/v2"
+			if tt.feed != "" {
+				feed = server.URL + tt.feed
+			}
+			mock := &MockCommandExecutor{Output: []byte("pushed")}
+			p := &ChocolateyPlugin{cmdExecutor: mock, httpClient: server.Client(), logger: discardLogger}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"package_path": "mypackage.{{version}}.nupkg",
+					"api_key":      "test-api-key",
+					"source":       "http://localhost:8080/",
+					"feed_url":     feed,
+					"on_exists":    tt.onExists,
+				},
+				Context: plugin.ReleaseContext{Version: tt.version},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got %+v", tt.wantSuccess, resp)
+			}
+			if tt.wantError != "" && !strings.Contains(resp.Error, tt.wantError) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.wantError, resp.Error)
+			}
+			if tt.wantOutcome != "" && resp.Outputs["outcome"] != tt.wantOutcome {
+				t.Errorf("expected outcome '%s', got '%v'", tt.wantOutcome, resp.Outputs["outcome"])
+			}
+			pushes := pushCommands(mock.Commands)
+			if pushed := len(pushes) == 1; pushed != tt.wantPush {
+				t.Fatalf("expected push=%v, got commands %v", tt.wantPush, mock.Commands)
+			}
+			if tt.wantPush {
+				hasForce := false
+				for _, arg := range pushes[0].Args {
+					if arg == "--force" {
+						hasForce = true
+					}
+				}
+				if hasForce != tt.wantForce {
+					t.Errorf("expected --force=%v, got args %v", tt.wantForce, pushes[0].Args)
+				}
+			}
+		})
+	}
+}
+
+func TestPushToSourceFailedOverwrite(t *testing.T) {
+	server := newTestFeed(t, "mypackage@1.0.0")
+	defer server.Close()
+
+	mock := &MockCommandExecutor{Output: []byte("409 (Conflict)"), Err: errors.New("exit status 1")}
+	p := &ChocolateyPlugin{cmdExecutor: mock, httpClient: server.Client(), logger: discardLogger}
+	cfg := p.parseConfig(map[string]any{
+		"package_path": "mypackage.{{version}}.nupkg",
+		"api_key":      "test-api-key",
+		"source":       "http://localhost:8080/",
+		"feed_url":     server.URL + "/api/v2",
+		"on_exists":    "force",
+	})
+
+	result := p.pushToSource(context.Background(), cfg, SourceConfig{URL: cfg.Source, APIKey: cfg.APIKey, Timeout: cfg.Timeout}, "mypackage.1.0.0.nupkg", "1.0.0")
+	if result.Success || result.Outcome != "" {
+		t.Errorf("expected a failed push without an outcome, got %+v", result)
+	}
+	if published := p.publishedPackages(); len(published) != 0 {
+		t.Errorf("expected nothing recorded as published, got %+v", published)
+	}
+}
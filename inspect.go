@@ -0,0 +1,154 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Description length limits enforced by the Chocolatey gallery.
+const (
+	minDescriptionLength = 30
+	maxDescriptionLength = 4000
+)
+
+// inspectionIssue is a single problem found while inspecting a package.
+type inspectionIssue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// inspectPackage opens a .nupkg, verifies the archive and its embedded nuspec,
+// and checks that it matches the expected id and version. An empty expectedID
+// skips the id check. The parsed nuspec is returned when it could be read.
+func inspectPackage(packagePath, expectedID, expectedVersion string) (*nuspec, []inspectionIssue) {
+	zr, err := zip.OpenReader(packagePath)
+	if err != nil {
+		return nil, []inspectionIssue{{Field: "archive", Message: fmt.Sprintf("cannot open package: %v", err)}}
+	}
+	defer zr.Close()
+
+	var issues []inspectionIssue
+	var manifests []*zip.File
+	for _, f := range zr.File {
+		// Reading each entry to EOF verifies its CRC.
+		if err := verifyZipEntry(f); err != nil {
+			issues = append(issues, inspectionIssue{Field: "archive", Message: fmt.Sprintf("corrupt entry %s: %v", f.Name, err)})
+		}
+		if !strings.Contains(f.Name, "/") && strings.HasSuffix(strings.ToLower(f.Name), ".nuspec") {
+			manifests = append(manifests, f)
+		}
+	}
+
+	switch len(manifests) {
+	case 0:
+		return nil, append(issues, inspectionIssue{Field: "nuspec", Message: "package does not contain a .nuspec at its root"})
+	case 1:
+	default:
+		return nil, append(issues, inspectionIssue{Field: "nuspec", Message: fmt.Sprintf("package contains %d .nuspec files at its root", len(manifests))})
+	}
+
+	rc, err := manifests[0].Open()
+	if err != nil {
+		return nil, append(issues, inspectionIssue{Field: "nuspec", Message: fmt.Sprintf("cannot read %s: %v", manifests[0].Name, err)})
+	}
+	data, err := io.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil {
+		return nil, append(issues, inspectionIssue{Field: "nuspec", Message: fmt.Sprintf("cannot read %s: %v", manifests[0].Name, err)})
+	}
+
+	spec, err := parseNuspec(data)
+	if err != nil {
+		return nil, append(issues, inspectionIssue{Field: "nuspec", Message: err.Error()})
+	}
+
+	return spec, append(issues, checkMetadata(spec, expectedID, expectedVersion)...)
+}
+
+// readPackageNuspec reads the nuspec at the root of a package without
+// verifying the rest of the archive.
+func readPackageNuspec(packagePath string) (*nuspec, error) {
+	zr, err := zip.OpenReader(packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open package: %w", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if strings.Contains(f.Name, "/") || !strings.HasSuffix(strings.ToLower(f.Name), ".nuspec") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", f.Name, err)
+		}
+		return parseNuspec(data)
+	}
+	return nil, fmt.Errorf("package does not contain a .nuspec at its root")
+}
+
+// checkMetadata checks the release identity and required metadata of a nuspec.
+func checkMetadata(spec *nuspec, expectedID, expectedVersion string) []inspectionIssue {
+	var issues []inspectionIssue
+	m := spec.Metadata
+
+	if expectedID != "" && !strings.EqualFold(m.ID, expectedID) {
+		issues = append(issues, inspectionIssue{Field: "id", Message: fmt.Sprintf("package id %q does not match expected %q", m.ID, expectedID)})
+	}
+	if !strings.EqualFold(strings.TrimSpace(m.Version), expectedVersion) {
+		issues = append(issues, inspectionIssue{Field: "version", Message: fmt.Sprintf("package version %q does not match release version %q", m.Version, expectedVersion)})
+	}
+
+	required := []struct{ field, value string }{
+		{"authors", m.Authors},
+		{"projectUrl", m.ProjectURL},
+		{"licenseUrl", m.LicenseURL},
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			issues = append(issues, inspectionIssue{Field: r.field, Message: fmt.Sprintf("<%s> is required", r.field)})
+		}
+	}
+
+	switch n := utf8.RuneCountInString(strings.TrimSpace(m.Description)); {
+	case n == 0:
+		issues = append(issues, inspectionIssue{Field: "description", Message: "<description> is required"})
+	case n < minDescriptionLength:
+		issues = append(issues, inspectionIssue{Field: "description", Message: fmt.Sprintf("<description> must be at least %d characters (got %d)", minDescriptionLength, n)})
+	case n > maxDescriptionLength:
+		issues = append(issues, inspectionIssue{Field: "description", Message: fmt.Sprintf("<description> must be at most %d characters (got %d)", maxDescriptionLength, n)})
+	}
+
+	return issues
+}
+
+// verifyZipEntry reads an entry to EOF so the zip reader validates its checksum.
+func verifyZipEntry(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
+
+// packageIDFromPath derives the package id from a conventional
+// <id>.<version>.nupkg filename. It returns "" if the name does not follow it.
+func packageIDFromPath(packagePath, version string) string {
+	base := filepath.Base(packagePath)
+	suffix := "." + version + ".nupkg"
+	if len(base) <= len(suffix) || !strings.EqualFold(base[len(base)-len(suffix):], suffix) {
+		return ""
+	}
+	return base[:len(base)-len(suffix)]
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// buildTestPackage packs nuspecContent into dir/<name> and returns the package path.
+func buildTestPackage(t *testing.T, dir, name, nuspecContent, version string) string {
+	t.Helper()
+	srcDir := filepath.Join(dir, "src-"+name)
+	if err := os.MkdirAll(srcDir, 0o755); err != nil {
+		t.Fatalf("failed to create source dir: %v", err)
+	}
+	nuspecPath := writeTestNuspec(t, srcDir, nuspecContent, map[string]string{"chocolateyInstall.ps1": "Install-ChocolateyPackage"})
+	outputPath := filepath.Join(dir, name)
+	if _, err := packNuspec(nuspecPath, outputPath, packOptions{Version: version}); err != nil {
+		t.Fatalf("failed to pack test package: %v", err)
+	}
+	return outputPath
+}
+
+// writeTestZip writes a zip file with the given entries.
+func writeTestZip(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create zip: %v", err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, body := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create entry: %v", err)
+		}
+		_, _ = w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+}
+
+// issueFields returns the fields of the given issues.
+func issueFields(issues []inspectionIssue) []string {
+	fields := make([]string, len(issues))
+	for i, issue := range issues {
+		fields[i] = issue.Field
+	}
+	return fields
+}
+
+func TestInspectPackage(t *testing.T) {
+	dir := t.TempDir()
+	valid := buildTestPackage(t, dir, "mypackage.1.0.0.nupkg", testNuspec, "1.0.0")
+
+	t.Run("valid package", func(t *testing.T) {
+		spec, issues := inspectPackage(valid, "mypackage", "1.0.0")
+		if len(issues) != 0 {
+			t.Errorf("unexpected issues: %v", issues)
+		}
+		if spec == nil || spec.Metadata.ID != "mypackage" {
+			t.Errorf("expected parsed nuspec, got %+v", spec)
+		}
+	})
+
+	t.Run("id and version mismatch", func(t *testing.T) {
+		_, issues := inspectPackage(valid, "otherpackage", "2.0.0")
+		fields := strings.Join(issueFields(issues), ",")
+		if fields != "id,version" {
+			t.Errorf("expected id and version issues, got %v", issues)
+		}
+	})
+
+	t.Run("missing metadata", func(t *testing.T) {
+		incomplete := `<package><metadata><id>mypackage</id><version>1.0.0</version><description>Too short</description></metadata></package>`
+		pkg := buildTestPackage(t, dir, "incomplete.1.0.0.nupkg", incomplete, "1.0.0")
+		_, issues := inspectPackage(pkg, "", "1.0.0")
+		fields := strings.Join(issueFields(issues), ",")
+		if fields != "authors,projectUrl,licenseUrl,description" {
+			t.Errorf("unexpected issues: %v", issues)
+		}
+		if !strings.Contains(issues[3].Message, "at least 30 characters") {
+			t.Errorf("expected description length issue, got %s", issues[3].Message)
+		}
+	})
+
+	t.Run("not a zip", func(t *testing.T) {
+		pkg := filepath.Join(dir, "garbage.1.0.0.nupkg")
+		if err := os.WriteFile(pkg, []byte("not a zip"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, issues := inspectPackage(pkg, "", "1.0.0")
+		if len(issues) != 1 || issues[0].Field != "archive" {
+			t.Errorf("expected archive issue, got %v", issues)
+		}
+	})
+
+	t.Run("corrupt entry", func(t *testing.T) {
+		data, err := os.ReadFile(valid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Flip a byte inside the first entry's compressed data.
+		corrupt := append([]byte(nil), data...)
+		corrupt[len("PK\x03\x04")+26+len("mypackage.nuspec")+10] ^= 0xff
+		pkg := filepath.Join(dir, "corrupt.1.0.0.nupkg")
+		if err := os.WriteFile(pkg, corrupt, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, issues := inspectPackage(pkg, "", "1.0.0")
+		if len(issues) == 0 || issues[0].Field != "archive" {
+			t.Errorf("expected corrupt archive issue, got %v", issues)
+		}
+	})
+
+	t.Run("missing nuspec", func(t *testing.T) {
+		pkg := filepath.Join(dir, "nonuspec.1.0.0.nupkg")
+		writeTestZip(t, pkg, map[string]string{"tools/chocolateyInstall.ps1": "x", "nested/pkg.nuspec": "<package/>"})
+		_, issues := inspectPackage(pkg, "", "1.0.0")
+		if len(issues) != 1 || issues[0].Field != "nuspec" {
+			t.Errorf("expected nuspec issue, got %v", issues)
+		}
+	})
+}
+
+func TestPackageIDFromPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		version  string
+		expected string
+	}{
+		{path: "dist/mypackage.1.0.0.nupkg", version: "1.0.0", expected: "mypackage"},
+		{path: "foo.install.2.0.0-beta0001.nupkg", version: "2.0.0-beta0001", expected: "foo.install"},
+		{path: "mypackage.NUPKG", version: "1.0.0", expected: ""},
+		{path: "mypackage.1.0.1.nupkg", version: "1.0.0", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := packageIDFromPath(tt.path, tt.version); got != tt.expected {
+				t.Errorf("packageIDFromPath(%s, %s) = %q, want %q", tt.path, tt.version, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecuteInspectsPackage(t *testing.T) {
+	dir := t.TempDir()
+	buildTestPackage(t, dir, "mypackage.1.0.0.nupkg", testNuspec, "1.0.0")
+	buildTestPackage(t, dir, "mypackage.1.1.0.nupkg", testNuspec, "1.0.0")
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	tests := []struct {
+		name       string
+		version    string
+		wantPushed bool
+		wantIssues []string
+	}{
+		{name: "matching package is pushed", version: "v1.0.0", wantPushed: true},
+		{name: "mismatched version is rejected", version: "v1.1.0", wantIssues: []string{"version"}},
+		{name: "missing package is rejected", version: "v1.2.0", wantIssues: []string{"archive"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockCommandExecutor{Output: []byte("pushed")}
+			p := &ChocolateyPlugin{cmdExecutor: mock, logger: discardLogger}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"package_path": "mypackage.{{version}}.nupkg",
+					"api_key":      "test-key",
+					"source":       "http://localhost:8080/",
+					"inspect":      true,
+				},
+				Context: plugin.ReleaseContext{Version: tt.version},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantPushed {
+				if !resp.Success || len(pushCommands(mock.Commands)) != 1 {
+					t.Errorf("expected push, got %+v (commands %v)", resp, mock.Commands)
+				}
+				return
+			}
+
+			if resp.Success || len(mock.Commands) != 0 {
+				t.Fatalf("expected inspection failure without push, got %+v", resp)
+			}
+			if !strings.Contains(resp.Error, "package inspection failed") {
+				t.Errorf("unexpected error: %s", resp.Error)
+			}
+			issues, ok := resp.Outputs["inspection_issues"].([]inspectionIssue)
+			if !ok {
+				t.Fatalf("expected inspection_issues output, got %T", resp.Outputs["inspection_issues"])
+			}
+			if got := strings.Join(issueFields(issues), ","); got != strings.Join(tt.wantIssues, ",") {
+				t.Errorf("expected issues %v, got %v", tt.wantIssues, issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Defaults for watch_moderation, in seconds.
+const (
+	defaultModerationTimeout      = 3600
+	defaultModerationPollInterval = 60
+)
+
+// Moderation states reported in the "moderation" output. Gallery values are
+// reported lowercased; these are the ones the watcher acts on.
+const (
+	ModerationSubmitted   = "submitted"
+	ModerationApproved    = "approved"
+	ModerationRejected    = "rejected"
+	ModerationExempted    = "exempted"
+	ModerationUnmoderated = "unmoderated"
+	// ModerationNotFound means the gallery does not list the version yet.
+	ModerationNotFound = "not_found"
+	// ModerationNotWatched means the feed is a NuGet v3 feed, which does not
+	// report moderation.
+	ModerationNotWatched = "not_watched"
+)
+
+// galleryEntry is the subset of an OData v2 package entry holding the
+// community repository moderation properties.
+type galleryEntry struct {
+	Properties struct {
+		PackageStatus                 string `xml:"PackageStatus"`
+		PackageSubmittedStatus        string `xml:"PackageSubmittedStatus"`
+		PackageValidationResultStatus string `xml:"PackageValidationResultStatus"`
+		PackageTestResultStatus       string `xml:"PackageTestResultStatus"`
+		PackageScanStatus             string `xml:"PackageScanStatus"`
+	} `xml:"properties"`
+}
+
+// moderationResult is the moderation state of one pushed package, reported
+// in the "moderation" output.
+type moderationResult struct {
+	PackageID string `json:"package_id"`
+	Version   string `json:"version"`
+	Source    string `json:"source"`
+	Status    string `json:"status"`
+	// SubmittedStatus tracks the maintainer and reviewer exchange.
+	SubmittedStatus string `json:"submitted_status,omitempty"`
+	// Validation, Verification and Scan are the automated check results of
+	// the package validator, the package verifier and the virus scan.
+	Validation   string `json:"validation,omitempty"`
+	Verification string `json:"verification,omitempty"`
+	Scan         string `json:"scan,omitempty"`
+	// Final is set once the state can no longer change without the maintainer.
+	Final bool   `json:"final"`
+	Error string `json:"error,omitempty"`
+}
+
+// update copies the moderation properties of entry into r.
+func (r *moderationResult) update(entry *galleryEntry) {
+	props := entry.Properties
+	r.Status = strings.ToLower(props.PackageStatus)
+	if r.Status == "" {
+		// Feeds without moderation do not expose the property.
+		r.Status = ModerationUnmoderated
+	}
+	r.SubmittedStatus = strings.ToLower(props.PackageSubmittedStatus)
+	r.Validation = strings.ToLower(props.PackageValidationResultStatus)
+	r.Verification = strings.ToLower(props.PackageTestResultStatus)
+	r.Scan = strings.ToLower(props.PackageScanStatus)
+
+	switch r.Status {
+	case ModerationApproved, ModerationRejected, ModerationExempted, ModerationUnmoderated:
+		r.Final = true
+	default:
+		// A failed automated check waits on the maintainer.
+		r.Final = r.Validation == "failing" || r.Verification == "failing" || r.Scan == "flagged"
+	}
+}
+
+// ModerationStatus fetches the gallery entry of id and version from an
+// OData v2 feed. A version the feed does not list yet returns nil.
+func (c *NuGetClient) ModerationStatus(ctx context.Context, feed, id, version string) (*galleryEntry, error) {
+	body, err := c.get(ctx, odataEntityURL(feed, id, version))
+	var pe *PushError
+	if errors.As(err, &pe) && pe.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry galleryEntry
+	if err := xml.Unmarshal(body, &entry); err != nil {
+		return nil, fmt.Errorf("invalid gallery entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// watchModeration polls the gallery of every package until each reaches a
+// final moderation state or moderation_timeout passes.
+func (p *ChocolateyPlugin) watchModeration(ctx context.Context, cfg *Config, packages []publishedPackage) []moderationResult {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.ModerationTimeout)*time.Second)
+	defer cancel()
+
+	results := make([]moderationResult, len(packages))
+	for i, pkg := range packages {
+		results[i] = moderationResult{PackageID: pkg.ID, Version: pkg.Version, Source: pkg.Source}
+		if isV3Feed(pkg.Feed) {
+			// Moderation properties exist only in OData v2 entries.
+			results[i].Status = ModerationNotWatched
+			results[i].Final = true
+			results[i].Error = fmt.Sprintf("moderation is not watched on %s: NuGet v3 feeds do not report it; set feed_url to the OData v2 feed to watch it", pkg.Feed)
+		}
+	}
+
+	client := p.getNuGetClient()
+	interval := time.Duration(cfg.ModerationPollInterval) * time.Second
+	for {
+		pending := 0
+		for i, pkg := range packages {
+			if results[i].Final {
+				continue
+			}
+			entry, err := client.ModerationStatus(ctx, pkg.Feed, pkg.ID, pkg.Version)
+			switch {
+			case err != nil && ctx.Err() != nil:
+				// Keep the last state seen before the deadline.
+			case err != nil:
+				results[i].Error = fmt.Sprintf("moderation status check failed: %v", err)
+			case entry == nil:
+				results[i].Status = ModerationNotFound
+				results[i].Error = ""
+			default:
+				results[i].update(entry)
+				results[i].Error = ""
+			}
+			if !results[i].Final {
+				pending++
+			}
+		}
+		if pending == 0 || p.wait(ctx, interval) != nil {
+			return results
+		}
+	}
+}
+
+// reportModeration adds the moderation results to a successful push response.
+func reportModeration(resp *plugin.ExecuteResponse, results []moderationResult) {
+	if resp.Outputs == nil {
+		resp.Outputs = make(map[string]any)
+	}
+	resp.Outputs["moderation"] = results
+
+	states := make([]string, len(results))
+	timedOut := false
+	for i, r := range results {
+		states[i] = fmt.Sprintf("%s %s %s", r.PackageID, r.Version, r.Status)
+		if !r.Final {
+			timedOut = true
+			states[i] += " (not final)"
+		}
+	}
+	resp.Outputs["moderation_timed_out"] = timedOut
+	resp.Message += fmt.Sprintf("; moderation: %s", strings.Join(states, ", "))
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// galleryEntryXML renders an OData v2 Atom entry with moderation properties;
+// empty values are written as nulls.
+func galleryEntryXML(status, submitted, validation, verification, scan string) string {
+	prop := func(name, value string) string {
+		if value == "" {
+			return fmt.Sprintf(`<d:%s m:null="true" />`, name)
+		}
+		return fmt.Sprintf(`<d:%s>%s</d:%s>`, name, value, name)
+	}
+	return `<?xml version="1.0" encoding="utf-8"?>
+<entry xmlns="http://www.w3.org/2005/Atom" xmlns:d="http://schemas.microsoft.com/ado/2007/08/dataservices" xmlns:m="http://schemas.microsoft.com/ado/2007/08/dataservices/metadata">
+  <m:properties>
+    <d:Id>mypackage</d:Id>
+    <d:Version>1.0.0</d:Version>` +
+		prop("PackageStatus", status) +
+		prop("PackageSubmittedStatus", submitted) +
+		prop("PackageValidationResultStatus", validation) +
+		prop("PackageTestResultStatus", verification) +
+		prop("PackageScanStatus", scan) + `
+  </m:properties>
+</entry>`
+}
+
+// newTestGallery serves the given entries for mypackage 1.0.0 in turn, one
+// per request, repeating the last. An empty entry responds 404.
+func newTestGallery(t *testing.T, entries ...string) (*httptest.Server, func() int) {
+	t.Helper()
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/Packages(Id='mypackage',Version='1.0.0')" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		entry := entries[min(requests, len(entries)-1)]
+		requests++
+		mu.Unlock()
+		if entry == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/atom+xml")
+		_, _ = w.Write([]byte(entry))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func TestExecuteWatchModeration(t *testing.T) {
+	tests := []struct {
+		name         string
+		entries      []string
+		feedURL      string
+		maxWaits     int
+		wantStatus   string
+		wantFinal    bool
+		wantRequests int
+	}{
+		{
+			name: "approved",
+			entries: []string{
+				"",
+				galleryEntryXML("Submitted", "Pending", "", "", ""),
+				galleryEntryXML("Submitted", "Ready", "Passing", "Passing", "NotFlagged"),
+				galleryEntryXML("Approved", "Ready", "Passing", "Passing", "NotFlagged"),
+			},
+			maxWaits:     10,
+			wantStatus:   ModerationApproved,
+			wantFinal:    true,
+			wantRequests: 4,
+		},
+		{
+			name: "validation failure",
+			entries: []string{
+				galleryEntryXML("Submitted", "Waiting", "Failing", "", ""),
+			},
+			maxWaits:     10,
+			wantStatus:   ModerationSubmitted,
+			wantFinal:    true,
+			wantRequests: 1,
+		},
+		{
+			name:         "unmoderated feed",
+			entries:      []string{galleryEntryXML("", "", "", "", "")},
+			maxWaits:     10,
+			wantStatus:   ModerationUnmoderated,
+			wantFinal:    true,
+			wantRequests: 1,
+		},
+		{
+			name:         "v3 feed",
+			entries:      []string{galleryEntryXML("Submitted", "Pending", "", "", "")},
+			feedURL:      "/v3/index.json",
+			maxWaits:     10,
+			wantStatus:   ModerationNotWatched,
+			wantFinal:    true,
+			wantRequests: 0,
+		},
+		{
+			name:         "deadline",
+			entries:      []string{galleryEntryXML("Submitted", "Pending", "Passing", "Pending", "")},
+			maxWaits:     2,
+			wantStatus:   ModerationSubmitted,
+			wantFinal:    false,
+			wantRequests: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := chdirTemp(t)
+			writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+			server, requests := newTestGallery(t, tt.entries...)
+
+			waits := 0
+			p := &ChocolateyPlugin{
+				cmdExecutor: &MockCommandExecutor{Output: []byte("pushed")},
+				httpClient:  server.Client(),
+				sleep: func(_ context.Context, d time.Duration) error {
+					if d != 5*time.Second {
+						t.Errorf("expected 5s poll interval, got %s", d)
+					}
+					if waits++; waits > tt.maxWaits {
+						return context.DeadlineExceeded
+					}
+					return nil
+				},
+				logger: discardLogger,
+			}
+			config := map[string]any{
+				"package_path":             "mypackage.{{version}}.nupkg",
+				"api_key":                  "test-api-key",
+				"source":                   server.URL + "/",
+				"watch_moderation":         true,
+				"moderation_poll_interval": 5,
+			}
+			if tt.feedURL != "" {
+				config["feed_url"] = server.URL + tt.feedURL
+			}
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got error: %s", resp.Error)
+			}
+
+			results, ok := resp.Outputs["moderation"].([]moderationResult)
+			if !ok || len(results) != 1 {
+				t.Fatalf("expected one moderation result, got %#v", resp.Outputs["moderation"])
+			}
+			r := results[0]
+			if r.PackageID != "mypackage" || r.Version != "1.0.0" || r.Source != server.URL+"/" {
+				t.Errorf("unexpected package in result: %+v", r)
+			}
+			if r.Status != tt.wantStatus || r.Final != tt.wantFinal {
+				t.Errorf("expected status %s final=%v, got %+v", tt.wantStatus, tt.wantFinal, r)
+			}
+			if resp.Outputs["moderation_timed_out"] != !tt.wantFinal {
+				t.Errorf("expected moderation_timed_out %v, got %v", !tt.wantFinal, resp.Outputs["moderation_timed_out"])
+			}
+			if tt.wantStatus == ModerationNotWatched && !strings.Contains(r.Error, "NuGet v3 feeds do not report it") {
+				t.Errorf("expected the skipped watch to be explained, got %+v", r)
+			}
+			if got := requests(); got != tt.wantRequests {
+				t.Errorf("expected %d gallery requests, got %d", tt.wantRequests, got)
+			}
+			if !strings.Contains(resp.Message, "moderation: mypackage 1.0.0 "+tt.wantStatus) {
+				t.Errorf("expected moderation in message, got %q", resp.Message)
+			}
+		})
+	}
+}
+
+func TestExecuteWatchModerationDryRun(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+	server, requests := newTestGallery(t, galleryEntryXML("Approved", "", "", "", ""))
+
+	p := &ChocolateyPlugin{httpClient: server.Client()}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"package_path":     "mypackage.{{version}}.nupkg",
+			"api_key":          "test-api-key",
+			"source":           server.URL + "/",
+			"watch_moderation": true,
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || resp.Outputs["moderation"] != nil || requests() != 0 {
+		t.Errorf("expected no moderation polling in a dry run, got %+v after %d requests", resp, requests())
+	}
+}
+
+func TestValidateModeration(t *testing.T) {
+	p := &ChocolateyPlugin{}
+	resp, err := p.Validate(context.Background(), map[string]any{
+		"package_path":             "mypackage.nupkg",
+		"source":                   "http://localhost:8080/",
+		"moderation_timeout":       0,
+		"moderation_poll_interval": -1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fields := make(map[string]bool)
+	for _, e := range resp.Errors {
+		fields[e.Field] = true
+	}
+	if !fields["moderation_timeout"] || !fields["moderation_poll_interval"] {
+		t.Errorf("expected moderation errors, got %+v", resp.Errors)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// nugetAPIKeyHeader is the header NuGet feeds read the API key from.
+const nugetAPIKeyHeader = "X-NuGet-ApiKey"
+
+// NuGetClient talks to NuGet v2 compatible feeds such as push.chocolatey.org.
+type NuGetClient struct {
+	// HTTPClient is used for all requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// PushError is returned when the feed rejects a push with a non-success status.
+type PushError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+// Error implements the error interface.
+func (e *PushError) Error() string {
+	return fmt.Sprintf("feed responded with %s", e.Status)
+}
+
+// Push uploads a .nupkg file using the NuGet v2 PUT /api/v2/package endpoint.
+// It returns the response body so callers can surface it like choco output.
+func (c *NuGetClient) Push(ctx context.Context, source, apiKey, packagePath string) ([]byte, error) {
+	file, err := os.Open(packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat package: %w", err)
+	}
+
+	// Build the multipart envelope around the file so the request can be
+	// streamed with a known Content-Length instead of buffering the package.
+	var head bytes.Buffer
+	mw := multipart.NewWriter(&head)
+	partHeader := make(textproto.MIMEHeader)
+	partHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name="package"; filename="%s"`, filepath.Base(packagePath)))
+	partHeader.Set("Content-Type", "application/octet-stream")
+	if _, err := mw.CreatePart(partHeader); err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	headLen := head.Len()
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	tail := head.String()[headLen:]
+	head.Truncate(headLen)
+
+	contentLength := int64(headLen) + info.Size() + int64(len(tail))
+	body := io.MultiReader(&head, file, strings.NewReader(tail))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, packageEndpoint(source), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.ContentLength = contentLength
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set(nugetAPIKeyHeader, apiKey)
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return respBody, &PushError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       string(respBody),
+		}
+	}
+
+	return respBody, nil
+}
+
+// Unlist hides a package version with the NuGet v2 DELETE
+// /api/v2/package/{id}/{version} endpoint. Feeds that delete instead of
+// unlisting treat it the same way.
+func (c *NuGetClient) Unlist(ctx context.Context, source, apiKey, id, version string) error {
+	endpoint := fmt.Sprintf("%s/%s/%s", packageEndpoint(source), url.PathEscape(id), url.PathEscape(version))
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set(nugetAPIKeyHeader, apiKey)
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return &PushError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       string(body),
+		}
+	}
+	return nil
+}
+
+// httpClient returns the configured HTTP client, defaulting to http.DefaultClient.
+func (c *NuGetClient) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// packageEndpoint returns the v2 package endpoint for a source URL.
+// Sources may be given as the feed root, the /api/v2 root, or the full endpoint.
+func packageEndpoint(source string) string {
+	base := strings.TrimRight(source, "/")
+	switch {
+	case strings.HasSuffix(base, "/api/v2/package"):
+		return base
+	case strings.HasSuffix(base, "/api/v2"):
+		return base + "/package"
+	default:
+		return base + "/api/v2/package"
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// writeTestPackage writes a dummy package file and returns its path.
+func writeTestPackage(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write package: %v", err)
+	}
+	return path
+}
+
+func TestNuGetClientPush(t *testing.T) {
+	var gotMethod, gotPath, gotKey, gotFilename, gotContent string
+	var gotLength int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotKey = r.Header.Get(nugetAPIKeyHeader)
+		gotLength = r.ContentLength
+
+		file, header, err := r.FormFile("package")
+		if err != nil {
+			t.Errorf("failed to read multipart package: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		gotFilename = header.Filename
+		gotContent = string(data)
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("Your package was pushed."))
+	}))
+	defer server.Close()
+
+	pkg := writeTestPackage(t, t.TempDir(), "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	client := &NuGetClient{HTTPClient: server.Client()}
+	output, err := client.Push(context.Background(), server.URL+"/", "secret-key", pkg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotMethod != http.MethodPut {
+		t.Errorf("expected PUT, got %s", gotMethod)
+	}
+	if gotPath != "/api/v2/package" {
+		t.Errorf("expected path '/api/v2/package', got '%s'", gotPath)
+	}
+	if gotKey != "secret-key" {
+		t.Errorf("expected API key header 'secret-key', got '%s'", gotKey)
+	}
+	if gotFilename != "mypackage.1.0.0.nupkg" {
+		t.Errorf("expected filename 'mypackage.1.0.0.nupkg', got '%s'", gotFilename)
+	}
+	if gotContent != "nupkg-bytes" {
+		t.Errorf("expected package content 'nupkg-bytes', got '%s'", gotContent)
+	}
+	if gotLength <= int64(len("nupkg-bytes")) {
+		t.Errorf("expected explicit content length, got %d", gotLength)
+	}
+	if string(output) != "Your package was pushed." {
+		t.Errorf("unexpected output: %s", output)
+	}
+}
+
+func TestNuGetClientPushRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte("A package with that version already exists"))
+	}))
+	defer server.Close()
+
+	pkg := writeTestPackage(t, t.TempDir(), "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	client := &NuGetClient{HTTPClient: server.Client()}
+	output, err := client.Push(context.Background(), server.URL, "secret-key", pkg)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	var pushErr *PushError
+	if !errors.As(err, &pushErr) {
+		t.Fatalf("expected *PushError, got %T", err)
+	}
+	if pushErr.StatusCode != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", pushErr.StatusCode)
+	}
+	if !strings.Contains(string(output), "already exists") {
+		t.Errorf("expected response body in output, got '%s'", output)
+	}
+}
+
+func TestNuGetClientPushMissingFile(t *testing.T) {
+	client := &NuGetClient{}
+	_, err := client.Push(context.Background(), "http://localhost/", "key", filepath.Join(t.TempDir(), "missing.nupkg"))
+	if err == nil || !strings.Contains(err.Error(), "failed to open package") {
+		t.Errorf("expected open error, got %v", err)
+	}
+}
+
+func TestPackageEndpoint(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{source: "https://push.chocolatey.org/", expected: "https://push.chocolatey.org/api/v2/package"},
+		{source: "https://push.chocolatey.org", expected: "https://push.chocolatey.org/api/v2/package"},
+		{source: "https://proget.example.com/nuget/choco/api/v2", expected: "https://proget.example.com/nuget/choco/api/v2/package"},
+		{source: "https://proget.example.com/api/v2/package/", expected: "https://proget.example.com/api/v2/package"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			if got := packageEndpoint(tt.source); got != tt.expected {
+				t.Errorf("packageEndpoint(%s) = %s, want %s", tt.source, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecuteNativePush(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get(nugetAPIKeyHeader) != "test-api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("pushed"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	pkg := writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	mock := &MockCommandExecutor{}
+	p := &ChocolateyPlugin{cmdExecutor: mock, httpClient: server.Client()}
+
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"package_path": filepath.Base(pkg),
+			"api_key":      "test-api-key",
+			"source":       server.URL + "/",
+			"push_method":  "native",
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+	if len(mock.Commands) != 0 {
+		t.Errorf("expected no choco commands, got %v", mock.Commands)
+	}
+	if resp.Outputs["package_path"] != "mypackage.1.0.0.nupkg" {
+		t.Errorf("unexpected package_path output: %v", resp.Outputs["package_path"])
+	}
+	if resp.Outputs["source"] != server.URL+"/" {
+		t.Errorf("unexpected source output: %v", resp.Outputs["source"])
+	}
+	if resp.Outputs["version"] != "1.0.0" {
+		t.Errorf("unexpected version output: %v", resp.Outputs["version"])
+	}
+	if resp.Outputs["output"] != "pushed" {
+		t.Errorf("unexpected output: %v", resp.Outputs["output"])
+	}
+}
+
+func TestExecuteNativePushFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("The specified API key is invalid"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	p := &ChocolateyPlugin{httpClient: server.Client()}
+
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"package_path": "mypackage.1.0.0.nupkg",
+			"api_key":      "bad-key",
+			"source":       server.URL,
+			"push_method":  "native",
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Success {
+		t.Fatal("expected failure")
+	}
+	if !strings.Contains(resp.Error, "native push failed") {
+		t.Errorf("expected native push failure, got '%s'", resp.Error)
+	}
+	if !strings.Contains(resp.Error, "API key is invalid") {
+		t.Errorf("expected feed response in error, got '%s'", resp.Error)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// nuspec is the subset of the NuGet package manifest the plugin reads.
+type nuspec struct {
+	XMLName  xml.Name       `xml:"package"`
+	Metadata nuspecMetadata `xml:"metadata"`
+}
+
+// nuspecMetadata holds the <metadata> element of a nuspec.
+type nuspecMetadata struct {
+	ID               string `xml:"id"`
+	Version          string `xml:"version"`
+	Title            string `xml:"title"`
+	Authors          string `xml:"authors"`
+	Owners           string `xml:"owners"`
+	Description      string `xml:"description"`
+	Summary          string `xml:"summary"`
+	ReleaseNotes     string `xml:"releaseNotes"`
+	Tags             string `xml:"tags"`
+	ProjectURL       string `xml:"projectUrl"`
+	LicenseURL       string `xml:"licenseUrl"`
+	IconURL          string `xml:"iconUrl"`
+	PackageSourceURL string `xml:"packageSourceUrl"`
+	ProjectSourceURL string `xml:"projectSourceUrl"`
+	DocsURL          string `xml:"docsUrl"`
+	BugTrackerURL    string `xml:"bugTrackerUrl"`
+
+	RequireLicenseAcceptance bool `xml:"requireLicenseAcceptance"`
+
+	Dependencies     []nuspecDependency      `xml:"dependencies>dependency"`
+	DependencyGroups []nuspecDependencyGroup `xml:"dependencies>group"`
+}
+
+// nuspecDependency is a <dependency> element.
+type nuspecDependency struct {
+	ID      string `xml:"id,attr"`
+	Version string `xml:"version,attr"`
+}
+
+// nuspecDependencyGroup is a <group> of dependencies for a target framework.
+type nuspecDependencyGroup struct {
+	Dependencies []nuspecDependency `xml:"dependency"`
+}
+
+// dependencyIDs returns the ids of all dependencies, including grouped ones.
+func (m nuspecMetadata) dependencyIDs() []string {
+	var ids []string
+	for _, d := range m.Dependencies {
+		ids = append(ids, d.ID)
+	}
+	for _, g := range m.DependencyGroups {
+		for _, d := range g.Dependencies {
+			ids = append(ids, d.ID)
+		}
+	}
+	return ids
+}
+
+// parseNuspec decodes a nuspec document.
+func parseNuspec(data []byte) (*nuspec, error) {
+	var spec nuspec
+	if err := xml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid nuspec: %w", err)
+	}
+	if spec.Metadata.ID == "" {
+		return nil, fmt.Errorf("invalid nuspec: missing <id>")
+	}
+	return &spec, nil
+}
+
+// xmlElement is the location of an element in a document: start and end
+// delimit it, tagEnd ends its start tag and closeStart begins its end tag.
+// An empty-element tag such as <releaseNotes /> ends at tagEnd.
+type xmlElement struct {
+	start, tagEnd, closeStart, end int
+	// attrs holds the decoded attributes of the start tag.
+	attrs []xml.Attr
+}
+
+// attr returns the value of the unqualified attribute name.
+func (e xmlElement) attr(name string) (string, bool) {
+	for _, a := range e.attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// metadataLayout locates the <metadata> element of a nuspec and its children.
+type metadataLayout struct {
+	// end is the offset of the </metadata> end tag.
+	end int
+	// children holds the first direct child of <metadata> with each local name.
+	children map[string]xmlElement
+	// dependencies lists the <dependency> elements of <dependencies>,
+	// including those in a <group>, in document order.
+	dependencies []xmlElement
+}
+
+// scanMetadata locates the <metadata> element of a nuspec by decoding the
+// document, so that markup inside comments and CDATA sections is never taken
+// for an element.
+func scanMetadata(doc []byte) (*metadataLayout, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(doc))
+	var names []string
+	var open []xmlElement
+	var layout *metadataLayout
+	offset := 0
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid nuspec: %w", err)
+		}
+		next := int(decoder.InputOffset())
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			names = append(names, t.Name.Local)
+			open = append(open, xmlElement{start: offset, tagEnd: next, attrs: t.Attr})
+			if layout == nil && len(names) == 2 && t.Name.Local == "metadata" {
+				layout = &metadataLayout{end: -1, children: make(map[string]xmlElement)}
+			}
+		case xml.EndElement:
+			el := open[len(open)-1]
+			el.closeStart, el.end = offset, next
+			name := names[len(names)-1]
+			names, open = names[:len(names)-1], open[:len(open)-1]
+
+			switch {
+			case layout == nil || layout.end >= 0:
+				// Outside <metadata>.
+			case len(names) == 1:
+				if el.end == el.tagEnd {
+					// An empty <metadata/> has nowhere to insert elements.
+					layout = nil
+				} else {
+					layout.end = offset
+				}
+			case len(names) == 2:
+				if _, ok := layout.children[name]; !ok {
+					layout.children[name] = el
+				}
+			case name == "dependency" && names[2] == "dependencies" && (len(names) == 3 || len(names) == 4 && names[3] == "group"):
+				layout.dependencies = append(layout.dependencies, el)
+			}
+		}
+		offset = next
+	}
+
+	if layout == nil || layout.end < 0 {
+		return nil, fmt.Errorf("invalid nuspec: missing <metadata> element")
+	}
+	return layout, nil
+}
+
+// setMetadataElement sets the text of a <metadata> child element, inserting the
+// element if it does not exist. The document is edited in place rather than
+// re-encoded so that formatting, comments and unknown elements are preserved.
+func setMetadataElement(doc []byte, name, value string) ([]byte, error) {
+	escaped, err := escapeXMLText(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to escape %s: %w", name, err)
+	}
+
+	layout, err := scanMetadata(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	el, ok := layout.children[name]
+	switch {
+	case !ok:
+		insertion := "  <" + name + ">" + escaped + "</" + name + ">\n  "
+		return spliceBytes(doc, layout.end, layout.end, insertion), nil
+	case el.end == el.tagEnd:
+		// Expand the empty-element tag, keeping its name and attributes.
+		tag := strings.TrimRight(strings.TrimSuffix(string(doc[el.start:el.tagEnd]), "/>"), " \t\r\n")
+		qualified := strings.Fields(tag[1:])[0]
+		return spliceBytes(doc, el.start, el.end, tag+">"+escaped+"</"+qualified+">"), nil
+	default:
+		return spliceBytes(doc, el.tagEnd, el.closeStart, escaped), nil
+	}
+}
+
+// pinDependencies sets the version range of every <dependency> whose id
+// satisfies pin to exactly version, e.g. [1.2.3]. Like setMetadataElement it
+// edits the document in place.
+func pinDependencies(doc []byte, version string, pin func(id string) bool) ([]byte, error) {
+	layout, err := scanMetadata(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	versionAttr := `version="[` + version + `]"`
+	// Edit from the end so that the offsets of earlier elements stay valid.
+	for i := len(layout.dependencies) - 1; i >= 0; i-- {
+		dep := layout.dependencies[i]
+		if id, ok := dep.attr("id"); !ok || !pin(id) {
+			continue
+		}
+
+		tag := string(doc[dep.start:dep.tagEnd])
+		attrs := tagAttrs(tag)
+		if a, ok := findTagAttr(attrs, "version"); ok {
+			tag = tag[:a.start] + versionAttr + tag[a.end:]
+		} else if a, ok := findTagAttr(attrs, "id"); ok {
+			// Insert the version after the id attribute.
+			tag = tag[:a.end] + " " + versionAttr + tag[a.end:]
+		}
+		doc = spliceBytes(doc, dep.start, dep.tagEnd, tag)
+	}
+	return doc, nil
+}
+
+// tagAttr is an attribute of a start tag, delimited by start and end.
+type tagAttr struct {
+	name       string
+	start, end int
+}
+
+// tagAttrs returns the attributes of a start tag the XML decoder accepted,
+// so that it is well-formed and attribute values never contain their quote.
+func tagAttrs(tag string) []tagAttr {
+	var attrs []tagAttr
+	i := strings.IndexFunc(tag, isXMLSpace)
+	for i >= 0 && i < len(tag) {
+		for i < len(tag) && isXMLSpace(rune(tag[i])) {
+			i++
+		}
+		if i == len(tag) || tag[i] == '/' || tag[i] == '>' {
+			break
+		}
+		start := i
+		for tag[i] != '=' && !isXMLSpace(rune(tag[i])) {
+			i++
+		}
+		name := tag[start:i]
+		for tag[i] != '"' && tag[i] != '\'' {
+			i++
+		}
+		i += strings.IndexByte(tag[i+1:], tag[i]) + 2
+		attrs = append(attrs, tagAttr{name: name, start: start, end: i})
+	}
+	return attrs
+}
+
+// findTagAttr returns the attribute called name.
+func findTagAttr(attrs []tagAttr, name string) (tagAttr, bool) {
+	for _, a := range attrs {
+		if a.name == name {
+			return a, true
+		}
+	}
+	return tagAttr{}, false
+}
+
+// isXMLSpace reports whether r is XML white space.
+func isXMLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\r' || r == '\n'
+}
+
+// escapeXMLText escapes a value for use as element text. Unlike xml.EscapeText
+// it keeps line breaks and tabs readable, which matters for multi-line notes.
+func escapeXMLText(value string) (string, error) {
+	var buf bytes.Buffer
+	if err := xml.EscapeText(&buf, []byte(value)); err != nil {
+		return "", err
+	}
+	return strings.NewReplacer("&#xA;", "\n", "&#x9;", "\t", "&#xD;", "").Replace(buf.String()), nil
+}
+
+// spliceBytes replaces doc[start:end] with replacement.
+func spliceBytes(doc []byte, start, end int, replacement string) []byte {
+	out := make([]byte, 0, len(doc)-(end-start)+len(replacement))
+	out = append(out, doc[:start]...)
+	out = append(out, replacement...)
+	return append(out, doc[end:]...)
+}
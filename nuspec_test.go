@@ -0,0 +1,175 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testNuspec = `<?xml version="1.0" encoding="utf-8"?>
+<!-- Test package -->
+<package xmlns="http://schemas.microsoft.com/packaging/2015/06/nuspec.xsd">
+  <metadata>
+    <id>mypackage</id>
+    <version>0.0.0</version>
+    <title>My Package</title>
+    <authors>Relicta Team</authors>
+    <projectUrl>https://github.com/relicta-tech/mypackage</projectUrl>
+    <licenseUrl>https://github.com/relicta-tech/mypackage/blob/main/LICENSE</licenseUrl>
+    <releaseNotes />
+    <description>My package does useful things on Windows machines.</description>
+    <tags>mypackage cli</tags>
+  </metadata>
+  <files>
+    <file src="tools\**" target="tools" />
+  </files>
+</package>
+`
+
+func TestParseNuspec(t *testing.T) {
+	spec, err := parseNuspec([]byte(testNuspec))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if spec.Metadata.ID != "mypackage" {
+		t.Errorf("expected id 'mypackage', got '%s'", spec.Metadata.ID)
+	}
+	if spec.Metadata.Version != "0.0.0" {
+		t.Errorf("expected version '0.0.0', got '%s'", spec.Metadata.Version)
+	}
+	if spec.Metadata.Authors != "Relicta Team" {
+		t.Errorf("expected authors 'Relicta Team', got '%s'", spec.Metadata.Authors)
+	}
+	if spec.Metadata.ProjectURL != "https://github.com/relicta-tech/mypackage" {
+		t.Errorf("unexpected projectUrl '%s'", spec.Metadata.ProjectURL)
+	}
+}
+
+func TestParseNuspecErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		errSubstr string
+	}{
+		{name: "malformed XML", doc: "<package><metadata>", errSubstr: "invalid nuspec"},
+		{name: "missing id", doc: "<package><metadata><version>1.0.0</version></metadata></package>", errSubstr: "missing <id>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseNuspec([]byte(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+				t.Errorf("expected error containing '%s', got %v", tt.errSubstr, err)
+			}
+		})
+	}
+}
+
+func TestSetMetadataElement(t *testing.T) {
+	tests := []struct {
+		name     string
+		element  string
+		value    string
+		contains string
+	}{
+		{name: "replace existing", element: "version", value: "1.2.3", contains: "<version>1.2.3</version>"},
+		{name: "replace self-closing", element: "releaseNotes", value: "Fixed bugs", contains: "<releaseNotes>Fixed bugs</releaseNotes>"},
+		{name: "insert missing", element: "summary", value: "Useful", contains: "<summary>Useful</summary>"},
+		{name: "escapes value", element: "title", value: "A & <B>", contains: "<title>A &amp; &lt;B&gt;</title>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := setMetadataElement([]byte(testNuspec), tt.element, tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(string(out), tt.contains) {
+				t.Errorf("expected output to contain '%s', got:\n%s", tt.contains, out)
+			}
+			if !strings.Contains(string(out), "<!-- Test package -->") {
+				t.Error("expected comments to be preserved")
+			}
+			if _, err := parseNuspec(out); err != nil {
+				t.Errorf("result is not a valid nuspec: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetMetadataElementIgnoresComments(t *testing.T) {
+	doc := strings.Replace(testNuspec, "<version>0.0.0</version>", `<!-- <version>9</version> -->
+    <summary><![CDATA[<version>8</version>]]></summary>
+    <version>0.0.0</version>`, 1)
+
+	out, err := setMetadataElement([]byte(doc), "version", "1.2.3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"<!-- <version>9</version> -->", "<![CDATA[<version>8</version>]]>", "<version>1.2.3</version>"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected output to contain %s, got:\n%s", want, out)
+		}
+	}
+	spec, err := parseNuspec(out)
+	if err != nil {
+		t.Fatalf("result is not a valid nuspec: %v", err)
+	}
+	if spec.Metadata.Version != "1.2.3" {
+		t.Errorf("expected version 1.2.3, got %s", spec.Metadata.Version)
+	}
+}
+
+func TestSetMetadataElementMissingMetadata(t *testing.T) {
+	_, err := setMetadataElement([]byte("<package></package>"), "version", "1.0.0")
+	if err == nil || !strings.Contains(err.Error(), "missing <metadata>") {
+		t.Errorf("expected missing metadata error, got %v", err)
+	}
+}
+
+func TestPinDependencies(t *testing.T) {
+	doc := strings.Replace(testNuspec, "</metadata>", `<dependencies>
+      <dependency id="mypackage.install" version="[1.0.0]" />
+      <dependency id='mypackage.portable'/>
+      <dependency id="chocolatey-core.extension" version="1.1.0" />
+      <!-- <dependency id="mypackage.commented" version="1.0.0" /> -->
+      <dependency version="0.9.0"
+        id="mypackage.tools"/>
+      <dependency id = "mypackage.spaced" />
+      <group targetFramework="net48">
+        <dependency id="mypackage.common" version="0.1.0" />
+      </group>
+    </dependencies>
+  </metadata>`, 1)
+	doc = strings.Replace(doc, "<files>", `<files>
+    <!-- <dependency id="mypackage.outside" version="1.0.0" /> -->`, 1)
+
+	pin := func(id string) bool { return strings.HasPrefix(id, "mypackage.") }
+	out, err := pinDependencies([]byte(doc), "2.0.0", pin)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`<dependency id="mypackage.install" version="[2.0.0]" />`,
+		`<dependency id='mypackage.portable' version="[2.0.0]"/>`,
+		`<dependency id="chocolatey-core.extension" version="1.1.0" />`,
+		`<dependency id="mypackage.common" version="[2.0.0]" />`,
+		`<!-- <dependency id="mypackage.commented" version="1.0.0" /> -->`,
+		"<dependency version=\"[2.0.0]\"\n        id=\"mypackage.tools\"/>",
+		`<dependency id = "mypackage.spaced" version="[2.0.0]" />`,
+		`<!-- <dependency id="mypackage.outside" version="1.0.0" /> -->`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected output to contain %s, got:\n%s", want, out)
+		}
+	}
+
+	spec, err := parseNuspec(out)
+	if err != nil {
+		t.Fatalf("result is not a valid nuspec: %v", err)
+	}
+	if ids := spec.Metadata.dependencyIDs(); strings.Join(ids, ",") != "mypackage.install,mypackage.portable,chocolatey-core.extension,mypackage.tools,mypackage.spaced,mypackage.common" {
+		t.Errorf("unexpected dependency ids: %v", ids)
+	}
+}
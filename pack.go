@@ -0,0 +1,413 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OPC part names and relationship types used by NuGet packages.
+const (
+	manifestRelationshipType       = "http://schemas.microsoft.com/packaging/2010/07/manifest"
+	corePropertiesRelationshipType = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
+	corePropertiesDir              = "package/services/metadata/core-properties"
+	packToolsDir                   = "tools"
+	packCreator                    = "relicta-plugin-chocolatey"
+)
+
+// packResult describes a package produced by packNuspec.
+type packResult struct {
+	ID       string
+	Version  string
+	Path     string
+	Size     int64
+	Checksum string
+	Files    []string
+	// Pinned lists the sibling dependencies pinned to Version.
+	Pinned []string
+}
+
+// packOptions controls how a package is built.
+type packOptions struct {
+	// Version is stamped into the manifest.
+	Version string
+	// Template, when set, renders the nuspec and install scripts as templates.
+	Template *templateData
+	// ReleaseNotes, when set, replaces the manifest <releaseNotes>.
+	ReleaseNotes string
+	// PinDependencies pins sibling dependencies to exactly Version.
+	PinDependencies bool
+	// Siblings lists the sibling package ids. If empty, siblings are the ids
+	// extending the package id, such as foo.install for foo.
+	Siblings []string
+}
+
+// isSibling reports whether dependencyID is a sibling of packageID.
+func (o packOptions) isSibling(packageID, dependencyID string) bool {
+	if len(o.Siblings) > 0 {
+		for _, id := range o.Siblings {
+			if strings.EqualFold(id, dependencyID) {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.HasPrefix(strings.ToLower(dependencyID), strings.ToLower(packageID)+".")
+}
+
+// packNuspec builds a .nupkg at outputPath from the nuspec at nuspecPath and the
+// tools/ directory next to it, stamping the release version into the manifest.
+func packNuspec(nuspecPath, outputPath string, opts packOptions) (*packResult, error) {
+	manifest, spec, err := buildManifest(nuspecPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := collectPackFiles(filepath.Join(filepath.Dir(nuspecPath), packToolsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Template != nil {
+		for i, f := range files {
+			if !isTemplateFile(f.target) {
+				continue
+			}
+			content, err := os.ReadFile(f.source)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", f.target, err)
+			}
+			if files[i].content, err = renderTemplate(f.target, content, opts.Template); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if dir := filepath.Dir(outputPath); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	// Write to a temporary file first so a failed pack never leaves a
+	// truncated package where the push step expects a valid one.
+	tmp, err := os.CreateTemp(filepath.Dir(outputPath), ".pack-*.nupkg")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create package: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(tmp, hash)}
+	entries, err := writePackage(counter, spec, manifest, files)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write package: %w", closeErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write package: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), outputPath); err != nil {
+		return nil, fmt.Errorf("failed to write package: %w", err)
+	}
+
+	return &packResult{
+		ID:       spec.Metadata.ID,
+		Version:  spec.Metadata.Version,
+		Path:     outputPath,
+		Size:     counter.n,
+		Checksum: hex.EncodeToString(hash.Sum(nil)),
+		Files:    entries,
+		Pinned:   pinnedSiblings(spec, opts),
+	}, nil
+}
+
+// buildManifest reads the nuspec at nuspecPath and applies templating, the
+// release version and release notes, returning the final manifest.
+func buildManifest(nuspecPath string, opts packOptions) ([]byte, *nuspec, error) {
+	manifest, err := os.ReadFile(nuspecPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read nuspec: %w", err)
+	}
+
+	if opts.Template != nil {
+		manifest, err = renderTemplate(filepath.Base(nuspecPath), manifest, opts.Template)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	manifest, err = setMetadataElement(manifest, "version", opts.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts.ReleaseNotes != "" {
+		manifest, err = setMetadataElement(manifest, "releaseNotes", opts.ReleaseNotes)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	spec, err := parseNuspec(manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	if spec.Metadata.Version != opts.Version {
+		return nil, nil, fmt.Errorf("invalid nuspec: <version> is %q after stamping %s; remove duplicate <version> elements", spec.Metadata.Version, opts.Version)
+	}
+
+	if opts.PinDependencies {
+		manifest, err = pinDependencies(manifest, opts.Version, func(id string) bool {
+			return opts.isSibling(spec.Metadata.ID, id)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if spec, err = parseNuspec(manifest); err != nil {
+			return nil, nil, err
+		}
+	}
+	return manifest, spec, nil
+}
+
+// pinnedSiblings returns the sibling dependencies buildManifest pinned.
+func pinnedSiblings(spec *nuspec, opts packOptions) []string {
+	pinned := []string{}
+	if !opts.PinDependencies {
+		return pinned
+	}
+	for _, id := range spec.Metadata.dependencyIDs() {
+		if opts.isSibling(spec.Metadata.ID, id) {
+			pinned = append(pinned, id)
+		}
+	}
+	return pinned
+}
+
+// packFile is a file to include in the package.
+type packFile struct {
+	// source is the path on disk.
+	source string
+	// target is the slash-separated path inside the package.
+	target string
+	// content, when set, replaces the file contents on disk.
+	content []byte
+}
+
+// collectPackFiles returns every regular file below the tools directory.
+// A missing tools directory yields no files.
+func collectPackFiles(toolsDir string) ([]packFile, error) {
+	var files []packFile
+	err := filepath.WalkDir(toolsDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.Dir(toolsDir), p)
+		if err != nil {
+			return err
+		}
+		files = append(files, packFile{source: p, target: filepath.ToSlash(rel)})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read tools directory: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].target < files[j].target })
+	return files, nil
+}
+
+// writePackage writes the OPC zip container for a package and returns the
+// names of the entries written.
+func writePackage(w io.Writer, spec *nuspec, manifest []byte, files []packFile) ([]string, error) {
+	zw := zip.NewWriter(w)
+	var entries []string
+
+	add := func(name string, data io.Reader) error {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(fw, data); err != nil {
+			return err
+		}
+		entries = append(entries, name)
+		return nil
+	}
+
+	manifestName := spec.Metadata.ID + ".nuspec"
+	if err := add(manifestName, bytes.NewReader(manifest)); err != nil {
+		return nil, fmt.Errorf("failed to write nuspec: %w", err)
+	}
+
+	extensions := map[string]bool{"nuspec": true}
+	for _, f := range files {
+		if f.content != nil {
+			if err := add(escapePartName(f.target), bytes.NewReader(f.content)); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", f.target, err)
+			}
+		} else {
+			src, err := os.Open(f.source)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", f.source, err)
+			}
+			err = add(escapePartName(f.target), src)
+			_ = src.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", f.target, err)
+			}
+		}
+		if ext := strings.TrimPrefix(path.Ext(f.target), "."); ext != "" {
+			extensions[strings.ToLower(ext)] = true
+		}
+	}
+
+	propsName := corePropertiesDir + "/" + newPartID() + ".psmdcp"
+	props, err := corePropertiesXML(spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := add(propsName, bytes.NewReader(props)); err != nil {
+		return nil, fmt.Errorf("failed to write core properties: %w", err)
+	}
+
+	rels := relationshipsXML(manifestName, propsName)
+	if err := add("_rels/.rels", strings.NewReader(rels)); err != nil {
+		return nil, fmt.Errorf("failed to write relationships: %w", err)
+	}
+
+	if err := add("[Content_Types].xml", strings.NewReader(contentTypesXML(extensions))); err != nil {
+		return nil, fmt.Errorf("failed to write content types: %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write package: %w", err)
+	}
+	return entries, nil
+}
+
+// escapePartName percent-encodes each segment of a part name as OPC requires.
+func escapePartName(name string) string {
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// newPartID returns a random identifier for generated part names.
+func newPartID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// contentTypesXML renders [Content_Types].xml for the given file extensions.
+func contentTypesXML(extensions map[string]bool) string {
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	sb.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml" />`)
+	sb.WriteString(`<Default Extension="psmdcp" ContentType="application/vnd.openxmlformats-package.core-properties+xml" />`)
+
+	exts := make([]string, 0, len(extensions))
+	for ext := range extensions {
+		if ext == "rels" || ext == "psmdcp" {
+			continue
+		}
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		fmt.Fprintf(&sb, `<Default Extension="%s" ContentType="application/octet" />`, xmlAttr(ext))
+	}
+
+	sb.WriteString(`</Types>`)
+	return sb.String()
+}
+
+// relationshipsXML renders _rels/.rels pointing at the manifest and core properties.
+func relationshipsXML(manifestName, propsName string) string {
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	fmt.Fprintf(&sb, `<Relationship Type="%s" Target="/%s" Id="R%s" />`, manifestRelationshipType, xmlAttr(escapePartName(manifestName)), newPartID()[:16])
+	fmt.Fprintf(&sb, `<Relationship Type="%s" Target="/%s" Id="R%s" />`, corePropertiesRelationshipType, xmlAttr(propsName), newPartID()[:16])
+	sb.WriteString(`</Relationships>`)
+	return sb.String()
+}
+
+// coreProperties is the OPC core properties part.
+type coreProperties struct {
+	XMLName        xml.Name `xml:"coreProperties"`
+	Xmlns          string   `xml:"xmlns,attr"`
+	XmlnsDC        string   `xml:"xmlns:dc,attr"`
+	XmlnsDCTerms   string   `xml:"xmlns:dcterms,attr"`
+	XmlnsXSI       string   `xml:"xmlns:xsi,attr"`
+	Creator        string   `xml:"dc:creator"`
+	Description    string   `xml:"dc:description"`
+	Identifier     string   `xml:"dc:identifier"`
+	Version        string   `xml:"version"`
+	Keywords       string   `xml:"keywords"`
+	LastModifiedBy string   `xml:"lastModifiedBy"`
+}
+
+// corePropertiesXML renders the .psmdcp part from nuspec metadata.
+func corePropertiesXML(spec *nuspec) ([]byte, error) {
+	props := coreProperties{
+		Xmlns:          "http://schemas.openxmlformats.org/package/2006/metadata/core-properties",
+		XmlnsDC:        "http://purl.org/dc/elements/1.1/",
+		XmlnsDCTerms:   "http://purl.org/dc/terms/",
+		XmlnsXSI:       "http://www.w3.org/2001/XMLSchema-instance",
+		Creator:        spec.Metadata.Authors,
+		Description:    spec.Metadata.Description,
+		Identifier:     spec.Metadata.ID,
+		Version:        spec.Metadata.Version,
+		Keywords:       spec.Metadata.Tags,
+		LastModifiedBy: packCreator,
+	}
+	data, err := xml.Marshal(props)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render core properties: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// xmlAttr escapes a value for use inside an XML attribute.
+func xmlAttr(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// countingWriter counts bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements io.Writer.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
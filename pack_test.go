@@ -0,0 +1,336 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// writeTestNuspec writes a nuspec and tools directory into dir and returns the nuspec path.
+func writeTestNuspec(t *testing.T, dir, content string, tools map[string]string) string {
+	t.Helper()
+	nuspecPath := filepath.Join(dir, "mypackage.nuspec")
+	if err := os.WriteFile(nuspecPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write nuspec: %v", err)
+	}
+	for name, body := range tools {
+		p := filepath.Join(dir, "tools", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("failed to create tools dir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatalf("failed to write tool file: %v", err)
+		}
+	}
+	return nuspecPath
+}
+
+// readZipEntries returns the contents of every entry in a zip file.
+func readZipEntries(t *testing.T, path string) map[string]string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("failed to open package: %v", err)
+	}
+	defer zr.Close()
+
+	entries := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open entry %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		entries[f.Name] = string(data)
+	}
+	return entries
+}
+
+func TestPackNuspec(t *testing.T) {
+	dir := t.TempDir()
+	nuspecPath := writeTestNuspec(t, dir, testNuspec, map[string]string{
+		"chocolateyInstall.ps1":   "Install-ChocolateyPackage",
+		"bin/my tool.exe":         "binary",
+		"chocolateyUninstall.ps1": "Uninstall-ChocolateyPackage",
+	})
+	outputPath := filepath.Join(dir, "out", "mypackage.1.2.3.nupkg")
+
+	result, err := packNuspec(nuspecPath, outputPath, packOptions{Version: "1.2.3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.ID != "mypackage" || result.Version != "1.2.3" {
+		t.Errorf("unexpected result id/version: %s %s", result.ID, result.Version)
+	}
+	if result.Size == 0 || len(result.Checksum) != 64 {
+		t.Errorf("expected size and sha256 checksum, got %d %q", result.Size, result.Checksum)
+	}
+
+	entries := readZipEntries(t, outputPath)
+
+	manifest, ok := entries["mypackage.nuspec"]
+	if !ok {
+		t.Fatalf("expected mypackage.nuspec in package, got %v", result.Files)
+	}
+	if !strings.Contains(manifest, "<version>1.2.3</version>") {
+		t.Errorf("expected stamped version in manifest, got:\n%s", manifest)
+	}
+
+	for _, name := range []string{"tools/chocolateyInstall.ps1", "tools/chocolateyUninstall.ps1", "tools/bin/my%20tool.exe", "_rels/.rels", "[Content_Types].xml"} {
+		if _, ok := entries[name]; !ok {
+			t.Errorf("expected entry %s in package", name)
+		}
+	}
+
+	var props string
+	for name, body := range entries {
+		if strings.HasPrefix(name, corePropertiesDir+"/") && strings.HasSuffix(name, ".psmdcp") {
+			props = body
+		}
+	}
+	if props == "" {
+		t.Fatal("expected core properties part in package")
+	}
+	if !strings.Contains(props, "<dc:identifier>mypackage</dc:identifier>") || !strings.Contains(props, "<version>1.2.3</version>") {
+		t.Errorf("unexpected core properties:\n%s", props)
+	}
+
+	if !strings.Contains(entries["_rels/.rels"], `Target="/mypackage.nuspec"`) {
+		t.Errorf("expected manifest relationship, got:\n%s", entries["_rels/.rels"])
+	}
+	if !strings.Contains(entries["[Content_Types].xml"], `Extension="ps1"`) {
+		t.Errorf("expected ps1 content type, got:\n%s", entries["[Content_Types].xml"])
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		t.Fatalf("failed to stat package: %v", err)
+	}
+	if info.Size() != result.Size {
+		t.Errorf("expected reported size %d to match file size %d", result.Size, info.Size())
+	}
+}
+
+func TestPackNuspecWithoutTools(t *testing.T) {
+	dir := t.TempDir()
+	nuspecPath := writeTestNuspec(t, dir, testNuspec, nil)
+
+	result, err := packNuspec(nuspecPath, filepath.Join(dir, "mypackage.1.0.0.nupkg"), packOptions{Version: "1.0.0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Files) != 4 {
+		t.Errorf("expected only nuspec and OPC parts, got %v", result.Files)
+	}
+}
+
+func TestPackNuspecErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("missing nuspec", func(t *testing.T) {
+		_, err := packNuspec(filepath.Join(dir, "missing.nuspec"), filepath.Join(dir, "out.nupkg"), packOptions{Version: "1.0.0"})
+		if err == nil || !strings.Contains(err.Error(), "failed to read nuspec") {
+			t.Errorf("expected read error, got %v", err)
+		}
+	})
+
+	t.Run("invalid nuspec", func(t *testing.T) {
+		nuspecPath := writeTestNuspec(t, dir, "<package><metadata></metadata></package>", nil)
+		output := filepath.Join(dir, "out.nupkg")
+		_, err := packNuspec(nuspecPath, output, packOptions{Version: "1.0.0"})
+		if err == nil || !strings.Contains(err.Error(), "missing <id>") {
+			t.Errorf("expected missing id error, got %v", err)
+		}
+		if _, err := os.Stat(output); !os.IsNotExist(err) {
+			t.Error("expected no package to be written on failure")
+		}
+	})
+
+	t.Run("duplicate version", func(t *testing.T) {
+		doc := strings.Replace(testNuspec, "<title>", "<version>0.0.1</version>\n    <title>", 1)
+		nuspecPath := writeTestNuspec(t, dir, doc, nil)
+		_, err := packNuspec(nuspecPath, filepath.Join(dir, "out.nupkg"), packOptions{Version: "1.0.0"})
+		if err == nil || !strings.Contains(err.Error(), `<version> is "0.0.1" after stamping 1.0.0`) {
+			t.Errorf("expected stamped version mismatch, got %v", err)
+		}
+	})
+}
+
+func TestPackNuspecPinsSiblings(t *testing.T) {
+	dir := t.TempDir()
+	doc := strings.Replace(testNuspec, "</metadata>", `<dependencies>
+      <dependency id="mypackage.install" version="[0.0.0]" />
+      <dependency id="chocolatey-core.extension" version="1.1.0" />
+      <dependency id="shared-runtime" version="1.0.0" />
+    </dependencies>
+  </metadata>`, 1)
+	nuspecPath := writeTestNuspec(t, dir, doc, nil)
+
+	tests := []struct {
+		name     string
+		siblings []string
+		pinned   []string
+	}{
+		{name: "ids extending the package id", pinned: []string{"mypackage.install"}},
+		{name: "explicit siblings", siblings: []string{"shared-runtime"}, pinned: []string{"shared-runtime"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(dir, "mypackage.1.2.3.nupkg")
+			result, err := packNuspec(nuspecPath, output, packOptions{Version: "1.2.3", PinDependencies: true, Siblings: tt.siblings})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(result.Pinned, ",") != strings.Join(tt.pinned, ",") {
+				t.Errorf("expected pinned %v, got %v", tt.pinned, result.Pinned)
+			}
+
+			spec, err := readPackageNuspec(output)
+			if err != nil {
+				t.Fatalf("failed to read packed nuspec: %v", err)
+			}
+			for _, dep := range spec.Metadata.Dependencies {
+				pinned := containsString(tt.pinned, dep.ID)
+				if pinned != (dep.Version == "[1.2.3]") {
+					t.Errorf("dependency %s: pinned=%v, version %s", dep.ID, pinned, dep.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutePrePublishPack(t *testing.T) {
+	dir := t.TempDir()
+	writeTestNuspec(t, dir, testNuspec, map[string]string{"chocolateyInstall.ps1": "Install-ChocolateyPackage"})
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	config := map[string]any{
+		"package_path": "dist/mypackage.{{version}}.nupkg",
+		"nuspec_path":  "mypackage.nuspec",
+		"pack":         true,
+	}
+
+	p := &ChocolateyPlugin{cmdExecutor: &MockCommandExecutor{Output: []byte("2.2.2")}}
+
+	t.Run("dry run", func(t *testing.T) {
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPrePublish,
+			Config:  config,
+			Context: plugin.ReleaseContext{Version: "v1.4.0"},
+			DryRun:  true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Success || resp.Message != "Would pack Chocolatey package" {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+		if _, err := os.Stat("dist/mypackage.1.4.0.nupkg"); !os.IsNotExist(err) {
+			t.Error("expected dry run not to write a package")
+		}
+	})
+
+	t.Run("pack", func(t *testing.T) {
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPrePublish,
+			Config:  config,
+			Context: plugin.ReleaseContext{Version: "v1.4.0"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Success {
+			t.Fatalf("expected success, got error: %s", resp.Error)
+		}
+		if resp.Outputs["package_path"] != "dist/mypackage.1.4.0.nupkg" {
+			t.Errorf("unexpected package_path output: %v", resp.Outputs["package_path"])
+		}
+		if resp.Outputs["package_id"] != "mypackage" {
+			t.Errorf("unexpected package_id output: %v", resp.Outputs["package_id"])
+		}
+		if len(resp.Artifacts) != 1 || resp.Artifacts[0].Path != "dist/mypackage.1.4.0.nupkg" {
+			t.Errorf("unexpected artifacts: %+v", resp.Artifacts)
+		}
+		if _, err := os.Stat("dist/mypackage.1.4.0.nupkg"); err != nil {
+			t.Errorf("expected package to be written: %v", err)
+		}
+	})
+
+	t.Run("package path list", func(t *testing.T) {
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: plugin.HookPrePublish,
+			Config: map[string]any{
+				"package_path": []any{"dist/list.{{version}}.nupkg"},
+				"nuspec_path":  "mypackage.nuspec",
+				"pack":         true,
+			},
+			Context: plugin.ReleaseContext{Version: "v1.5.0"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Success || resp.Outputs["package_path"] != "dist/list.1.5.0.nupkg" {
+			t.Errorf("expected the listed package path to be packed, got %+v", resp)
+		}
+	})
+
+	t.Run("invalid nuspec path", func(t *testing.T) {
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: plugin.HookPrePublish,
+			Config: map[string]any{
+				"package_path": "mypackage.nupkg",
+				"nuspec_path":  "../mypackage.nuspec",
+				"pack":         true,
+			},
+			Context: plugin.ReleaseContext{Version: "v1.4.0"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Success || !strings.Contains(resp.Error, "invalid nuspec path") {
+			t.Errorf("expected invalid nuspec path error, got %+v", resp)
+		}
+	})
+}
+
+func TestValidateNuspecPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		errSubstr string
+	}{
+		{name: "valid", path: "choco/mypackage.nuspec"},
+		{name: "empty", path: "", errSubstr: "cannot be empty"},
+		{name: "traversal", path: "../mypackage.nuspec", errSubstr: "path traversal"},
+		{name: "wrong extension", path: "mypackage.xml", errSubstr: "must end with .nuspec"},
+		{name: "invalid characters", path: "my$package.nuspec", errSubstr: "disallowed characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateNuspecPath(tt.path)
+			if tt.errSubstr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+				t.Errorf("expected error containing '%s', got %v", tt.errSubstr, err)
+			}
+		})
+	}
+}
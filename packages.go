@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// packageResult is the outcome of publishing one package when package_path
+// matches several packages, reported in the "packages" output.
+type packageResult struct {
+	PackagePath string         `json:"package_path"`
+	Success     bool           `json:"success"`
+	Message     string         `json:"message,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Outputs     map[string]any `json:"outputs,omitempty"`
+}
+
+// isGlobPattern reports whether a package path contains glob metacharacters.
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// substitutePlaceholders replaces the release placeholders in a package path.
+func substitutePlaceholders(path, version, tag string) string {
+	path = strings.ReplaceAll(path, "{{version}}", version)
+	return strings.ReplaceAll(path, "{{tag}}", tag)
+}
+
+// validatePackagePattern validates a package glob before it is expanded.
+// Matches are validated individually with validatePackagePath.
+func validatePackagePattern(pattern string) error {
+	// Check length.
+	if len(pattern) > 512 {
+		return fmt.Errorf("package path too long (max 512 characters)")
+	}
+
+	// Check for path traversal attempts.
+	cleaned := filepath.Clean(pattern)
+	if strings.HasPrefix(cleaned, "..") || strings.Contains(cleaned, string(filepath.Separator)+"..") {
+		return fmt.Errorf("path traversal detected: cannot use '..' to escape working directory")
+	}
+
+	// Must end with .nupkg.
+	if !strings.HasSuffix(strings.ToLower(pattern), ".nupkg") {
+		return fmt.Errorf("package path must end with .nupkg")
+	}
+
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid glob pattern: %w", err)
+	}
+
+	return nil
+}
+
+// expandPackagePaths substitutes placeholders in each configured package path
+// and expands globs. Paths keep their configured order; the matches of each
+// glob are sorted with pushOrderLess. Duplicates are dropped.
+func expandPackagePaths(patterns []string, version, tag string) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, pattern := range patterns {
+		pattern = substitutePlaceholders(pattern, version, tag)
+		if !isGlobPattern(pattern) {
+			add(pattern)
+			continue
+		}
+
+		if err := validatePackagePattern(pattern); err != nil {
+			return nil, err
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern: %w", err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no packages match %s", pattern)
+		}
+		sort.Slice(matches, func(i, j int) bool {
+			return pushOrderLess(matches[i], matches[j], version)
+		})
+		for _, match := range matches {
+			add(filepath.ToSlash(match))
+		}
+	}
+
+	return paths, nil
+}
+
+// pushOrderLess orders packages by id, placing a package after the packages
+// whose ids extend it. Chocolatey names the variants of a meta package
+// foo as foo.install and foo.portable, so foo is pushed after both.
+func pushOrderLess(a, b, version string) bool {
+	return pushOrderKey(a, version) < pushOrderKey(b, version)
+}
+
+// pushOrderKey returns the sort key for a package path. The id is terminated
+// with '~', which sorts after every character allowed in an id.
+func pushOrderKey(path, version string) string {
+	id := packageIDFromPath(path, version)
+	if id == "" {
+		id = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return strings.ToLower(filepath.Dir(path) + "/" + id + "~")
+}
+
+// orderByDependencies orders packages so that each is pushed after the
+// packages it depends on, read from their embedded nuspecs. Packages keep
+// their given order where dependencies allow it, and packages whose nuspec
+// cannot be read are treated as having no dependencies. It also returns the
+// packages among paths that each package depends on.
+func orderByDependencies(paths []string, version string) ([]string, map[string][]string, error) {
+	byID := make(map[string]string, len(paths))
+	depIDs := make(map[string][]string, len(paths))
+	for _, path := range paths {
+		id := packageIDFromPath(path, version)
+		if spec, err := readPackageNuspec(path); err == nil {
+			id = spec.Metadata.ID
+			depIDs[path] = spec.Metadata.dependencyIDs()
+		}
+		if id != "" {
+			byID[strings.ToLower(id)] = path
+		}
+	}
+
+	dependsOn := make(map[string][]string, len(paths))
+	for _, path := range paths {
+		for _, id := range depIDs[path] {
+			dep, ok := byID[strings.ToLower(id)]
+			if !ok || dep == path || containsString(dependsOn[path], dep) {
+				continue
+			}
+			dependsOn[path] = append(dependsOn[path], dep)
+		}
+	}
+
+	ordered := make([]string, 0, len(paths))
+	done := make(map[string]bool, len(paths))
+	for len(ordered) < len(paths) {
+		next := ""
+		for _, path := range paths {
+			if !done[path] && allDone(dependsOn[path], done) {
+				next = path
+				break
+			}
+		}
+		if next == "" {
+			var cycle []string
+			for _, path := range paths {
+				if !done[path] {
+					cycle = append(cycle, path)
+				}
+			}
+			return nil, nil, fmt.Errorf("dependency cycle between %s", strings.Join(cycle, ", "))
+		}
+		done[next] = true
+		ordered = append(ordered, next)
+	}
+
+	return ordered, dependsOn, nil
+}
+
+// allDone reports whether every path is marked in done.
+func allDone(paths []string, done map[string]bool) bool {
+	for _, path := range paths {
+		if !done[path] {
+			return false
+		}
+	}
+	return true
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
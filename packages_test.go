@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// funcExecutor runs a function for each command, recording the calls.
+type funcExecutor struct {
+	Commands []ExecutedCommand
+	Fn       func(name string, args ...string) ([]byte, error)
+}
+
+// Run records the command and delegates to Fn.
+func (f *funcExecutor) Run(_ context.Context, name string, args ...string) ([]byte, error) {
+	f.Commands = append(f.Commands, ExecutedCommand{Name: name, Args: args})
+	return f.Fn(name, args...)
+}
+
+// chdirTemp changes into a new temporary directory for the rest of the test.
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	return dir
+}
+
+func TestExpandPackagePaths(t *testing.T) {
+	dir := chdirTemp(t)
+	for _, name := range []string{"foo.1.0.0.nupkg", "foo.install.1.0.0.nupkg", "foo.portable.1.0.0.nupkg", "bar.1.0.0.nupkg", "foo.0.9.0.nupkg"} {
+		writeTestPackage(t, dir, name, "nupkg-bytes")
+	}
+	if err := os.Mkdir(filepath.Join(dir, "out"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestPackage(t, filepath.Join(dir, "out"), "baz.1.0.0.nupkg", "nupkg-bytes")
+
+	tests := []struct {
+		name      string
+		patterns  []string
+		expected  []string
+		errSubstr string
+	}{
+		{
+			name:     "literal path is not checked",
+			patterns: []string{"missing.{{version}}.nupkg"},
+			expected: []string{"missing.1.0.0.nupkg"},
+		},
+		{
+			name:     "meta package after variants",
+			patterns: []string{"*.{{version}}.nupkg"},
+			expected: []string{"bar.1.0.0.nupkg", "foo.install.1.0.0.nupkg", "foo.portable.1.0.0.nupkg", "foo.1.0.0.nupkg"},
+		},
+		{
+			name:     "list keeps configured order and drops duplicates",
+			patterns: []string{"out/*.nupkg", "foo.*.{{version}}.nupkg", "foo.{{version}}.nupkg", "out/baz.{{version}}.nupkg"},
+			expected: []string{"out/baz.1.0.0.nupkg", "foo.install.1.0.0.nupkg", "foo.portable.1.0.0.nupkg", "foo.1.0.0.nupkg"},
+		},
+		{name: "no matches", patterns: []string{"qux.*.nupkg"}, errSubstr: "no packages match qux.*.nupkg"},
+		{name: "traversal", patterns: []string{"../*.nupkg"}, errSubstr: "path traversal"},
+		{name: "wrong extension", patterns: []string{"*.zip"}, errSubstr: "must end with .nupkg"},
+		{name: "malformed glob", patterns: []string{"foo[.nupkg"}, errSubstr: "invalid glob pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandPackagePaths(tt.patterns, "1.0.0", "v1.0.0")
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Errorf("expected error containing '%s', got %v", tt.errSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// dependentNuspec returns testNuspec with the given id and dependencies.
+func dependentNuspec(id string, deps ...string) string {
+	doc := strings.Replace(testNuspec, "<id>mypackage</id>", "<id>"+id+"</id>", 1)
+	if len(deps) == 0 {
+		return doc
+	}
+	var b strings.Builder
+	b.WriteString("<dependencies>\n")
+	for _, dep := range deps {
+		b.WriteString(`      <dependency id="` + dep + `" version="0.0.0" />` + "\n")
+	}
+	b.WriteString("    </dependencies>\n  </metadata>")
+	return strings.Replace(doc, "</metadata>", b.String(), 1)
+}
+
+func TestOrderByDependencies(t *testing.T) {
+	dir := chdirTemp(t)
+	buildTestPackage(t, dir, "app.1.0.0.nupkg", dependentNuspec("app", "app.install", "chocolatey-core.extension"), "1.0.0")
+	buildTestPackage(t, dir, "app.install.1.0.0.nupkg", dependentNuspec("app.install", "app.common"), "1.0.0")
+	buildTestPackage(t, dir, "app.common.1.0.0.nupkg", dependentNuspec("app.common"), "1.0.0")
+	writeTestPackage(t, dir, "other.1.0.0.nupkg", "not-a-zip")
+
+	ordered, dependsOn, err := orderByDependencies([]string{"app.1.0.0.nupkg", "other.1.0.0.nupkg", "app.install.1.0.0.nupkg", "app.common.1.0.0.nupkg"}, "1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "other.1.0.0.nupkg,app.common.1.0.0.nupkg,app.install.1.0.0.nupkg,app.1.0.0.nupkg"
+	if strings.Join(ordered, ",") != expected {
+		t.Errorf("expected order %s, got %v", expected, ordered)
+	}
+	if strings.Join(dependsOn["app.1.0.0.nupkg"], ",") != "app.install.1.0.0.nupkg" {
+		t.Errorf("expected external dependencies to be ignored, got %v", dependsOn["app.1.0.0.nupkg"])
+	}
+
+	// Dependencies come from the parsed nuspec: commented-out ones do not
+	// count, and attribute order and dependency groups do not matter.
+	doc := strings.Replace(dependentNuspec("app.common", "chocolatey-core.extension"), "    </dependencies>", `      <!-- <dependency id="app" version="0.0.0" /> -->
+      <group targetFramework="net48">
+        <dependency version="0.0.0"
+          id="other" />
+      </group>
+    </dependencies>`, 1)
+	buildTestPackage(t, dir, "app.common.1.0.0.nupkg", doc, "1.0.0")
+	buildTestPackage(t, dir, "other.1.0.0.nupkg", dependentNuspec("other"), "1.0.0")
+	ordered, _, err = orderByDependencies([]string{"app.1.0.0.nupkg", "app.common.1.0.0.nupkg", "app.install.1.0.0.nupkg", "other.1.0.0.nupkg"}, "1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = "other.1.0.0.nupkg,app.common.1.0.0.nupkg,app.install.1.0.0.nupkg,app.1.0.0.nupkg"
+	if strings.Join(ordered, ",") != expected {
+		t.Errorf("expected order %s, got %v", expected, ordered)
+	}
+
+	buildTestPackage(t, dir, "app.common.1.0.0.nupkg", dependentNuspec("app.common", "app"), "1.0.0")
+	_, _, err = orderByDependencies([]string{"app.1.0.0.nupkg", "app.install.1.0.0.nupkg", "app.common.1.0.0.nupkg"}, "1.0.0")
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+		t.Errorf("expected dependency cycle error, got %v", err)
+	}
+}
+
+func TestExecuteMultiplePackages(t *testing.T) {
+	dir := chdirTemp(t)
+	buildTestPackage(t, dir, "foo.1.0.0.nupkg", dependentNuspec("foo", "foo.install"), "1.0.0")
+	buildTestPackage(t, dir, "foo.install.1.0.0.nupkg", dependentNuspec("foo.install"), "1.0.0")
+	buildTestPackage(t, dir, "foo.portable.1.0.0.nupkg", dependentNuspec("foo.portable"), "1.0.0")
+
+	tests := []struct {
+		name        string
+		failOn      string
+		dryRun      bool
+		wantSuccess bool
+		wantError   string
+		wantPushed  []string
+		wantResults map[string]string
+	}{
+		{
+			name:        "dependencies pushed first",
+			wantSuccess: true,
+			wantPushed:  []string{"foo.install.1.0.0.nupkg", "foo.1.0.0.nupkg", "foo.portable.1.0.0.nupkg"},
+		},
+		{
+			name:       "dependent not pushed after dependency failure",
+			failOn:     "foo.install.1.0.0.nupkg",
+			wantError:  "push failed for 2 of 3 package(s): foo.install.1.0.0.nupkg, foo.1.0.0.nupkg",
+			wantPushed: []string{"foo.install.1.0.0.nupkg", "foo.portable.1.0.0.nupkg"},
+			wantResults: map[string]string{
+				"foo.install.1.0.0.nupkg": "choco push failed",
+				"foo.1.0.0.nupkg":         "not pushed: dependency foo.install.1.0.0.nupkg failed",
+			},
+		},
+		{
+			name:       "independent failure does not block others",
+			failOn:     "foo.portable.1.0.0.nupkg",
+			wantError:  "push failed for 1 of 3 package(s): foo.portable.1.0.0.nupkg",
+			wantPushed: []string{"foo.install.1.0.0.nupkg", "foo.1.0.0.nupkg", "foo.portable.1.0.0.nupkg"},
+			wantResults: map[string]string{
+				"foo.portable.1.0.0.nupkg": "choco push failed",
+			},
+		},
+		{
+			name:        "dry run",
+			dryRun:      true,
+			wantSuccess: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &funcExecutor{Fn: func(_ string, args ...string) ([]byte, error) {
+				if args[1] == tt.failOn {
+					return []byte("409 Conflict"), errors.New("exit status 1")
+				}
+				return []byte("pushed"), nil
+			}}
+			p := &ChocolateyPlugin{cmdExecutor: exec, logger: discardLogger}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"package_path": []any{"foo.{{version}}.nupkg", "foo.*.{{version}}.nupkg"},
+					"api_key":      "test-key",
+					"source":       "http://localhost:8080/",
+				},
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+				DryRun:  tt.dryRun,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got %+v", tt.wantSuccess, resp)
+			}
+			if tt.wantError != "" && resp.Error != tt.wantError {
+				t.Errorf("expected error '%s', got '%s'", tt.wantError, resp.Error)
+			}
+
+			var pushed []string
+			for _, cmd := range pushCommands(exec.Commands) {
+				pushed = append(pushed, cmd.Args[1])
+			}
+			if strings.Join(pushed, ",") != strings.Join(tt.wantPushed, ",") {
+				t.Errorf("expected pushes %v, got %v", tt.wantPushed, pushed)
+			}
+
+			results, _ := resp.Outputs["packages"].([]packageResult)
+			if len(results) != 3 {
+				t.Fatalf("expected 3 package results, got %v", resp.Outputs["packages"])
+			}
+			for _, r := range results {
+				wantErr, wantFailed := tt.wantResults[r.PackagePath]
+				if r.Success == wantFailed || !strings.Contains(r.Error, wantErr) {
+					t.Errorf("unexpected result for %s: %+v", r.PackagePath, r)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatePackagePathForms(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     map[string]any
+		wantErrMsg string
+	}{
+		{name: "glob", config: map[string]any{"package_path": "out/*.{{version}}.nupkg"}},
+		{name: "list", config: map[string]any{"package_path": []any{"foo.install.{{version}}.nupkg", "foo.{{version}}.nupkg"}}},
+		{name: "empty list", config: map[string]any{"package_path": []any{}}, wantErrMsg: "package path is required"},
+		{name: "glob traversal", config: map[string]any{"package_path": "../*.nupkg"}, wantErrMsg: "path traversal detected: cannot use '..' to escape working directory"},
+		{name: "list entry not nupkg", config: map[string]any{"package_path": []any{"foo.1.0.0.nupkg", "foo.zip"}}, wantErrMsg: "package path must end with .nupkg"},
+		{name: "pack with glob", config: map[string]any{"package_path": "*.nupkg", "pack": true, "nuspec_path": "foo.nuspec"}, wantErrMsg: "pack requires a single package path without globs"},
+	}
+
+	p := &ChocolateyPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{"source": "http://localhost:8080/"}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+
+			resp, err := p.Validate(context.Background(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErrMsg == "" {
+				if !resp.Valid {
+					t.Errorf("expected valid, got errors: %v", resp.Errors)
+				}
+				return
+			}
+			if resp.Valid || len(resp.Errors) != 1 || resp.Errors[0].Field != "package_path" || resp.Errors[0].Message != tt.wantErrMsg {
+				t.Errorf("expected package_path error '%s', got %v", tt.wantErrMsg, resp.Errors)
+			}
+		})
+	}
+}
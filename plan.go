@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Planned actions reported in the "plan" output.
+const (
+	// PlanPush pushes a version that is not on the feed, or whose presence is unknown.
+	PlanPush = "push"
+	// PlanSkip skips a version already on the feed with on_exists: skip.
+	PlanSkip = "skip"
+	// PlanOverwrite pushes over an existing version with on_exists: force.
+	PlanOverwrite = "overwrite"
+	// PlanConflict pushes a version already on the feed with on_exists: fail,
+	// which the feed is expected to reject.
+	PlanConflict = "conflict"
+)
+
+// planEntry is what post-publish will do with one package on one source.
+type planEntry struct {
+	PackagePath string `json:"package_path"`
+	PackageID   string `json:"package_id,omitempty"`
+	Source      string `json:"source"`
+	Feed        string `json:"feed"`
+	// Exists is nil when the feed could not be queried.
+	Exists *bool  `json:"exists,omitempty"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// plannedAction returns the action for a version given whether it exists.
+func plannedAction(onExists string, exists *bool) string {
+	if exists == nil || !*exists {
+		return PlanPush
+	}
+	switch onExists {
+	case OnExistsSkip:
+		return PlanSkip
+	case OnExistsForce:
+		return PlanOverwrite
+	default:
+		return PlanConflict
+	}
+}
+
+// planSources returns the sources post-publish pushes to, scoped like
+// pushToSource.
+func planSources(cfg *Config) []SourceConfig {
+	if len(cfg.Sources) > 0 {
+		return cfg.Sources
+	}
+	return []SourceConfig{{URL: cfg.Source, Timeout: cfg.Timeout}}
+}
+
+// plan resolves the package paths, Chocolatey version and sources of the
+// release and checks the feeds for each version, without changing anything.
+func (p *ChocolateyPlugin) plan(ctx context.Context, cfg *Config, releaseCtx plugin.ReleaseContext) *plugin.ExecuteResponse {
+	version, err := p.packageVersion(cfg, releaseCtx)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("invalid version: %v", err),
+		}
+	}
+
+	// Packages built later in the release may not exist yet.
+	var warnings []string
+	packagePaths, err := expandPackagePaths(cfg.PackagePaths, version, releaseCtx.TagName)
+	if err != nil {
+		warnings = append(warnings, err.Error())
+		packagePaths = nil
+		for _, pattern := range cfg.PackagePaths {
+			packagePaths = append(packagePaths, substitutePlaceholders(pattern, version, releaseCtx.TagName))
+		}
+	}
+
+	sources := planSources(cfg)
+	var entries []planEntry
+	for _, packagePath := range packagePaths {
+		id, idErr := packageID(packagePath, version)
+		for _, src := range sources {
+			srcCfg := *cfg
+			srcCfg.Source = src.URL
+			if len(cfg.Sources) > 0 {
+				srcCfg.FeedURL = ""
+			}
+			entry := planEntry{
+				PackagePath: packagePath,
+				PackageID:   id,
+				Source:      src.URL,
+				Feed:        feedURL(&srcCfg),
+			}
+
+			switch {
+			case idErr != nil:
+				entry.Error = fmt.Sprintf("existence not checked: %v", idErr)
+			default:
+				checkCtx, cancel := context.WithTimeout(ctx, time.Duration(src.Timeout)*time.Second)
+				exists, err := p.getNuGetClient().PackageExists(checkCtx, entry.Feed, id, version)
+				cancel()
+				if err != nil {
+					entry.Error = fmt.Sprintf("existence check failed: %v", err)
+				} else {
+					entry.Exists = &exists
+				}
+			}
+			entry.Action = plannedAction(cfg.OnExists, entry.Exists)
+			entries = append(entries, entry)
+		}
+	}
+
+	urls := make([]string, len(sources))
+	for i, src := range sources {
+		urls[i] = src.URL
+	}
+	outputs := map[string]any{
+		"version":       version,
+		"package_paths": packagePaths,
+		"sources":       urls,
+		"push_method":   cfg.PushMethod,
+		"on_exists":     cfg.OnExists,
+		"plan":          entries,
+	}
+	if len(warnings) > 0 {
+		outputs["warnings"] = warnings
+	}
+
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Would publish %d Chocolatey package(s) at version %s to %d source(s)", len(packagePaths), version, len(sources)),
+		Outputs: outputs,
+	}
+}
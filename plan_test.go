@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestExecutePostPlan(t *testing.T) {
+	server := newTestFeed(t, "mypackage@1.0.0")
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		config      map[string]any
+		version     string
+		wantVersion string
+		wantEntries []planEntry
+		wantWarning string
+		wantError   string
+	}{
+		{
+			name:        "existing version with on_exists skip",
+			config:      map[string]any{"on_exists": "skip"},
+			version:     "v1.0.0",
+			wantVersion: "1.0.0",
+			wantEntries: []planEntry{{PackagePath: "mypackage.1.0.0.nupkg", PackageID: "mypackage", Source: "http://localhost:8080/", Feed: server.URL + "/api/v2", Action: PlanSkip}},
+		},
+		{
+			name:        "existing version with on_exists fail",
+			version:     "v1.0.0",
+			wantVersion: "1.0.0",
+			wantEntries: []planEntry{{PackagePath: "mypackage.1.0.0.nupkg", PackageID: "mypackage", Source: "http://localhost:8080/", Feed: server.URL + "/api/v2", Action: PlanConflict}},
+		},
+		{
+			name:        "new version with fix segment",
+			config:      map[string]any{"package_fix_version": "counter", "package_fix_counter": 2},
+			version:     "v1.2.0-beta.1",
+			wantVersion: "1.2.0.2-beta0001",
+			wantEntries: []planEntry{{PackagePath: "mypackage.1.2.0.2-beta0001.nupkg", PackageID: "mypackage", Source: "http://localhost:8080/", Feed: server.URL + "/api/v2", Action: PlanPush}},
+		},
+		{
+			name:        "unreachable feed",
+			config:      map[string]any{"feed_url": server.URL + "/broken", "on_exists": "force"},
+			version:     "v1.0.0",
+			wantVersion: "1.0.0",
+			wantEntries: []planEntry{{PackagePath: "mypackage.1.0.0.nupkg", PackageID: "mypackage", Source: "http://localhost:8080/", Feed: server.URL + "/broken", Action: PlanPush, Error: "existence check failed"}},
+		},
+		{
+			name: "unmatched glob and several sources",
+			config: map[string]any{
+				"package_path": "dist/*.nupkg",
+				"sources": []any{
+					map[string]any{"url": "http://localhost:8080/", "api_key": "key-a"},
+					map[string]any{"url": "http://127.0.0.1:8081/", "api_key": "key-b"},
+				},
+			},
+			version:     "v1.0.0",
+			wantVersion: "1.0.0",
+			wantEntries: []planEntry{
+				{PackagePath: "dist/*.nupkg", Source: "http://localhost:8080/", Feed: "http://localhost:8080/api/v2", Action: PlanPush, Error: "existence not checked"},
+				{PackagePath: "dist/*.nupkg", Source: "http://127.0.0.1:8081/", Feed: "http://127.0.0.1:8081/api/v2", Action: PlanPush, Error: "existence not checked"},
+			},
+			wantWarning: "no packages match dist/*.nupkg",
+		},
+		{
+			name:      "invalid version",
+			version:   "not-a-version",
+			wantError: "invalid version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chdirTemp(t)
+			config := map[string]any{
+				"package_path": "mypackage.{{version}}.nupkg",
+				"api_key":      "test-api-key",
+				"source":       "http://localhost:8080/",
+				"feed_url":     server.URL + "/api/v2",
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+			if config["sources"] != nil {
+				delete(config, "feed_url")
+			}
+
+			mock := &MockCommandExecutor{}
+			p := &ChocolateyPlugin{cmdExecutor: mock, httpClient: server.Client()}
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPlan,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: tt.version, TagName: tt.version},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(mock.Commands) != 0 {
+				t.Errorf("expected no commands during planning, got %v", mock.Commands)
+			}
+
+			if tt.wantError != "" {
+				if resp.Success || !strings.Contains(resp.Error, tt.wantError) {
+					t.Errorf("expected error containing %q, got %+v", tt.wantError, resp)
+				}
+				return
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got error: %s", resp.Error)
+			}
+			if resp.Outputs["version"] != tt.wantVersion {
+				t.Errorf("expected version %s, got %v", tt.wantVersion, resp.Outputs["version"])
+			}
+
+			entries, _ := resp.Outputs["plan"].([]planEntry)
+			if len(entries) != len(tt.wantEntries) {
+				t.Fatalf("expected %d plan entries, got %+v", len(tt.wantEntries), entries)
+			}
+			for i, want := range tt.wantEntries {
+				got := entries[i]
+				if got.PackagePath != want.PackagePath || got.PackageID != want.PackageID || got.Source != want.Source ||
+					got.Feed != want.Feed || got.Action != want.Action || !strings.Contains(got.Error, want.Error) || (want.Error == "") != (got.Error == "") {
+					t.Errorf("plan entry %d: expected %+v, got %+v", i, want, got)
+				}
+				if (got.Exists != nil) != (want.Error == "") {
+					t.Errorf("plan entry %d: expected exists to be set only when checked, got %+v", i, got)
+				}
+			}
+
+			warnings, _ := resp.Outputs["warnings"].([]string)
+			if tt.wantWarning != "" && (len(warnings) != 1 || warnings[0] != tt.wantWarning) {
+				t.Errorf("expected warning %q, got %q", tt.wantWarning, warnings)
+			}
+		})
+	}
+}
@@ -3,13 +3,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net"
+	"net/http"
 	"net/url"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
@@ -21,6 +26,9 @@ var (
 	// Package path pattern: alphanumerics, dots, dashes, underscores, forward slashes.
 	// Case-insensitive for the .nupkg extension.
 	packagePathPattern = regexp.MustCompile(`(?i)^[a-zA-Z0-9][a-zA-Z0-9._/-]*\.nupkg$`)
+
+	// Nuspec filename pattern: same character set as packages, with a .nuspec extension.
+	nuspecPathPattern = regexp.MustCompile(`(?i)^[a-zA-Z0-9][a-zA-Z0-9._-]*\.nuspec$`)
 )
 
 // CommandExecutor abstracts command execution for testability.
@@ -28,19 +36,86 @@ type CommandExecutor interface {
 	Run(ctx context.Context, name string, args ...string) ([]byte, error)
 }
 
+// EnvCommandExecutor is implemented by executors that can add environment
+// variables to the command they run.
+type EnvCommandExecutor interface {
+	RunEnv(ctx context.Context, env []string, name string, args ...string) ([]byte, error)
+}
+
+// StreamCommandExecutor is implemented by executors that can pass each line
+// of output to onLine while the command runs. The returned output is bounded
+// to its last outputTailSize bytes.
+type StreamCommandExecutor interface {
+	RunStream(ctx context.Context, env []string, onLine func(line string), name string, args ...string) ([]byte, error)
+}
+
 // RealCommandExecutor executes actual system commands.
 type RealCommandExecutor struct{}
 
-// Run executes a command and returns combined output.
+// Run executes a command and returns the tail of its combined output.
 func (e *RealCommandExecutor) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return e.RunStream(ctx, nil, nil, name, args...)
+}
+
+// RunEnv executes a command with env added to the plugin's environment and
+// returns the tail of its combined output.
+func (e *RealCommandExecutor) RunEnv(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	return e.RunStream(ctx, env, nil, name, args...)
+}
+
+// RunStream executes a command with env added to the plugin's environment,
+// passing each line of combined output to onLine, and returns its tail.
+func (e *RealCommandExecutor) RunStream(ctx context.Context, env []string, onLine func(line string), name string, args ...string) ([]byte, error) {
 	cmd := exec.CommandContext(ctx, name, args...)
-	return cmd.CombinedOutput()
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return runStreaming(cmd, onLine)
 }
 
+// StdoutCommandExecutor executes system commands and returns only their
+// standard output, so diagnostics on stderr are not mistaken for secrets.
+type StdoutCommandExecutor struct{}
+
+// Run executes a command and returns its standard output.
+func (e *StdoutCommandExecutor) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	return cmd.Output()
+}
+
+// Push methods supported by the plugin.
+const (
+	// PushMethodChoco shells out to the choco executable.
+	PushMethodChoco = "choco"
+	// PushMethodNative uploads the package directly over the NuGet v2 HTTP API.
+	PushMethodNative = "native"
+)
+
 // ChocolateyPlugin implements the Publish packages to Chocolatey (Windows) plugin.
 type ChocolateyPlugin struct {
 	// cmdExecutor is used for executing shell commands. If nil, uses RealCommandExecutor.
 	cmdExecutor CommandExecutor
+	// keyExecutor runs exec: API key sources. If nil, uses StdoutCommandExecutor.
+	keyExecutor CommandExecutor
+	// httpClient is used for native feed requests. If nil, uses http.DefaultClient.
+	httpClient *http.Client
+	// now returns the current time. If nil, uses time.Now.
+	now func() time.Time
+	// sleep waits between push retries. If nil, waits on a timer.
+	sleep func(ctx context.Context, d time.Duration) error
+	// logger receives streamed choco output. If nil, logs to stderr, which
+	// the host records in its plugin log.
+	logger *log.Logger
+
+	// mu guards release, releaseDate and published.
+	mu sync.Mutex
+	// release identifies the release served by the last hook; see startRelease.
+	release string
+	// releaseDate is the package_fix_version date of the release, taken by the
+	// first hook that needs it.
+	releaseDate time.Time
+	// published lists the packages pushed during the current release.
+	published []publishedPackage
 }
 
 // getExecutor returns the command executor, defaulting to RealCommandExecutor.
@@ -51,13 +126,136 @@ func (p *ChocolateyPlugin) getExecutor() CommandExecutor {
 	return &RealCommandExecutor{}
 }
 
+// getKeyExecutor returns the executor for exec: API key sources, defaulting
+// to StdoutCommandExecutor.
+func (p *ChocolateyPlugin) getKeyExecutor() CommandExecutor {
+	if p.keyExecutor != nil {
+		return p.keyExecutor
+	}
+	return &StdoutCommandExecutor{}
+}
+
+// getLogger returns the logger for streamed command output, defaulting to
+// stderr.
+func (p *ChocolateyPlugin) getLogger() *log.Logger {
+	if p.logger != nil {
+		return p.logger
+	}
+	return log.New(os.Stderr, "", 0)
+}
+
+// clock returns the current time, defaulting to time.Now.
+func (p *ChocolateyPlugin) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+// startRelease records the release served by a hook. A hook for another
+// release than the last one starts over with the state of the new release.
+// Hooks that run before the version is known keep the current release.
+func (p *ChocolateyPlugin) startRelease(releaseCtx plugin.ReleaseContext) {
+	if releaseCtx.Version == "" {
+		return
+	}
+	key := releaseCtx.Version + "@" + releaseCtx.CommitSHA
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key == p.release {
+		return
+	}
+	p.release = key
+	p.releaseDate = time.Time{}
+	p.published = nil
+}
+
+// endRelease forgets the current release once its outcome has been handled,
+// so nothing of it carries over to the next release.
+func (p *ChocolateyPlugin) endRelease() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.release = ""
+	p.releaseDate = time.Time{}
+	p.published = nil
+}
+
+// fixDate returns the package_fix_version date of the current release. The
+// first call reads the clock, so the hooks of a release agree on the date.
+func (p *ChocolateyPlugin) fixDate() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.releaseDate.IsZero() {
+		p.releaseDate = p.clock()
+	}
+	return p.releaseDate
+}
+
+// getNuGetClient returns a NuGet client using the plugin's HTTP client.
+func (p *ChocolateyPlugin) getNuGetClient() *NuGetClient {
+	return &NuGetClient{HTTPClient: p.httpClient}
+}
+
 // Config represents the Chocolatey plugin configuration.
 type Config struct {
-	APIKey      string
-	Source      string
+	APIKey string
+	// APIKeySource resolves APIKey at push time; see resolveAPIKey.
+	APIKeySource string
+	Source       string
+	// PackagePath is the first configured package path, the one pack builds.
 	PackagePath string
-	Timeout     int
-	Force       bool
+	// PackagePaths holds every configured package path or glob, including PackagePath.
+	PackagePaths []string
+	Timeout      int
+	PushMethod   string
+	// ChocoPath is the choco executable, checked against MinChocoVersion
+	// during pre-publish.
+	ChocoPath       string
+	MinChocoVersion string
+	// ExtraArgs are appended to choco push; see validateExtraArgs.
+	ExtraArgs []string
+	// Env holds environment variables set for choco.
+	Env map[string]string
+	// Executor selects where choco runs; see ExecutorDocker.
+	Executor         string
+	ContainerRuntime string
+	ContainerImage   string
+
+	NuspecPath string
+	Pack       bool
+	Template   bool
+	Vars       map[string]string
+
+	PinDependencies bool
+	SiblingPackages []string
+
+	ReleaseNotesMode string
+	ReleaseNotesURL  string
+
+	Version           string
+	PackageFixMode    string
+	PackageFixCounter int
+
+	Inspect bool
+
+	OnExists string
+	FeedURL  string
+
+	Sources     []SourceConfig
+	MaxParallel int
+
+	Retries      int
+	RetryBackoff int
+
+	ValidateNuspec bool
+	DisabledRules  []string
+
+	WatchModeration        bool
+	ModerationTimeout      int
+	ModerationPollInterval int
+
+	Rollback string
 }
 
 // GetInfo returns plugin metadata.
@@ -68,51 +266,425 @@ func (p *ChocolateyPlugin) GetInfo() plugin.Info {
 		Description: "Publish packages to Chocolatey (Windows)",
 		Author:      "Relicta Team",
 		Hooks: []plugin.Hook{
+			plugin.HookPostPlan,
+			plugin.HookPreApprove,
+			plugin.HookPrePublish,
 			plugin.HookPostPublish,
+			plugin.HookOnSuccess,
+			plugin.HookOnError,
 		},
 		ConfigSchema: `{
 			"type": "object",
 			"properties": {
 				"api_key": {"type": "string", "description": "Chocolatey API key (or use CHOCOLATEY_API_KEY env)"},
+				"api_key_source": {"type": "string", "description": "Resolve the API key at push time from env:NAME, file:/path or exec:command"},
 				"source": {"type": "string", "description": "Chocolatey source URL", "default": "https://push.chocolatey.org/"},
-				"package_path": {"type": "string", "description": "Path to .nupkg file (supports {{version}} placeholder)"},
+				"package_path": {"oneOf": [{"type": "string"}, {"type": "array", "items": {"type": "string"}}], "description": "Path, glob or list of paths to .nupkg files (supports {{version}} placeholder)"},
 				"timeout": {"type": "integer", "description": "Push timeout in seconds", "default": 300},
-				"force": {"type": "boolean", "description": "Force push even if package exists", "default": false}
+				"force": {"type": "boolean", "description": "Deprecated: use on_exists: force", "default": false},
+				"on_exists": {"type": "string", "enum": ["fail", "skip", "force"], "description": "What to do when the version already exists on the feed", "default": "fail"},
+				"feed_url": {"type": "string", "description": "Feed queried for existing packages (OData v2 root or v3 index.json)"},
+				"sources": {"type": "array", "description": "Push to several feeds instead of source/api_key", "items": {"type": "object", "properties": {
+					"url": {"type": "string", "description": "Feed push URL"},
+					"api_key": {"type": "string", "description": "API key for this feed"},
+					"api_key_env": {"type": "string", "description": "Environment variable holding the API key"},
+					"api_key_source": {"type": "string", "description": "Resolve the API key at push time from env:NAME, file:/path or exec:command"},
+					"timeout": {"type": "integer", "description": "Push timeout in seconds (defaults to timeout)"},
+					"on_failure": {"type": "string", "enum": ["fail", "continue"], "description": "Whether a failed push fails the release", "default": "fail"}
+				}, "required": ["url"]}},
+				"max_parallel": {"type": "integer", "description": "Maximum number of sources pushed concurrently", "default": 4},
+				"retries": {"type": "integer", "description": "Times a push failing with a transient error (timeout, 5xx, connection reset) is retried", "default": 0, "minimum": 0, "maximum": 10},
+				"retry_backoff": {"type": "integer", "description": "Seconds before the first retry, doubled for each further retry", "default": 2},
+				"push_method": {"type": "string", "enum": ["choco", "native"], "description": "Push with the choco executable or the built-in NuGet client", "default": "choco"},
+				"choco_path": {"type": "string", "description": "Path to the choco executable", "default": "choco"},
+				"min_choco_version": {"type": "string", "description": "Minimum choco version required by the pre-publish preflight", "default": "1.0.0"},
+				"extra_args": {"type": "array", "items": {"type": "string"}, "description": "Additional choco push options from an allowlist, e.g. --skip-compatibility-checks"},
+				"env": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Environment variables set for choco"},
+				"executor": {"type": "string", "enum": ["local", "docker"], "description": "Run choco on the runner or inside a container", "default": "local"},
+				"container_runtime": {"type": "string", "description": "Container CLI used by executor docker, e.g. docker or podman", "default": "docker"},
+				"container_image": {"type": "string", "description": "Image providing choco for executor docker", "default": "chocolatey/choco:latest"},
+				"nuspec_path": {"type": "string", "description": "Path to the .nuspec used when pack is enabled"},
+				"pack": {"type": "boolean", "description": "Build package_path from nuspec_path during pre-publish", "default": false},
+				"template": {"type": "boolean", "description": "Render the nuspec and PowerShell scripts as Go templates when packing", "default": false},
+				"pin_dependencies": {"type": "boolean", "description": "Pin sibling package dependencies to the release version when packing", "default": false},
+				"sibling_packages": {"type": "array", "items": {"type": "string"}, "description": "Package ids pinned by pin_dependencies (defaults to ids extending the package id)"},
+				"vars": {"type": "object", "description": "Custom values exposed to templates as .Vars", "additionalProperties": {"type": "string"}},
+				"release_notes_mode": {"type": "string", "enum": ["inline", "link", "none"], "description": "How release notes are written into <releaseNotes> when packing", "default": "inline"},
+				"release_notes_url": {"type": "string", "description": "Link to the full release notes (defaults to the repository release page)"},
+				"version": {"type": "string", "description": "Override the release version published to Chocolatey"},
+				"package_fix_version": {"type": "string", "enum": ["none", "date", "counter"], "description": "Append a fourth package fix segment to the version", "default": "none"},
+				"package_fix_counter": {"type": "integer", "description": "Package fix segment used when package_fix_version is counter"},
+				"inspect": {"type": "boolean", "description": "Open the package and check its nuspec before pushing", "default": false},
+				"validate_nuspec": {"type": "boolean", "description": "Check nuspec_path against the community repository validator rules during pre-publish", "default": false},
+				"disabled_rules": {"type": "array", "items": {"type": "string"}, "description": "Validator rule IDs to skip"},
+				"watch_moderation": {"type": "boolean", "description": "Poll the gallery after pushing until moderation reaches a final state", "default": false},
+				"moderation_timeout": {"type": "integer", "description": "Seconds to wait for a final moderation state", "default": 3600},
+				"moderation_poll_interval": {"type": "integer", "description": "Seconds between moderation status checks", "default": 60},
+				"rollback": {"type": "string", "enum": ["unlist", "none"], "description": "Unlist the versions pushed by this run when the release fails", "default": "none"}
 			},
 			"required": ["package_path"]
 		}`,
 	}
 }
 
-// Execute runs the plugin for a given hook.
+// Execute runs the plugin for a given hook. Configured secrets are masked in
+// the response before it leaves the plugin.
 func (p *ChocolateyPlugin) Execute(ctx context.Context, req plugin.ExecuteRequest) (*plugin.ExecuteResponse, error) {
 	cfg := p.parseConfig(req.Config)
+	p.startRelease(req.Context)
+	resp, err := p.execute(ctx, cfg, req)
+
+	// Build the redactor afterwards to include API keys resolved while pushing.
+	redact := newRedactor(cfg)
+	if err != nil {
+		return nil, errors.New(redact.String(err.Error()))
+	}
+	return redact.Response(resp), nil
+}
 
+// execute dispatches a hook.
+func (p *ChocolateyPlugin) execute(ctx context.Context, cfg *Config, req plugin.ExecuteRequest) (*plugin.ExecuteResponse, error) {
 	switch req.Hook {
+	case plugin.HookPostPlan:
+		return p.plan(ctx, cfg, req.Context), nil
+	case plugin.HookPreApprove:
+		return p.preApprove(ctx, cfg, req.Context, req.DryRun), nil
+	case plugin.HookPrePublish:
+		// Check the choco toolchain before anything is published.
+		if cfg.PushMethod != PushMethodNative {
+			resp := p.chocoPreflight(ctx, cfg)
+			if !resp.Success || !cfg.Pack && !cfg.ValidateNuspec {
+				return resp, nil
+			}
+		}
+		if cfg.Pack || cfg.ValidateNuspec {
+			return p.prePublish(cfg, req.Context, req.DryRun)
+		}
 	case plugin.HookPostPublish:
-		return p.pushPackage(ctx, cfg, req.Context, req.DryRun)
-	default:
+		before := len(p.publishedPackages())
+		resp, err := p.pushPackage(ctx, cfg, req.Context, req.DryRun)
+		if err != nil || !resp.Success || !cfg.WatchModeration {
+			return resp, err
+		}
+		// Follow moderation of the packages pushed by this hook.
+		if pushed := p.publishedPackages()[before:]; len(pushed) > 0 {
+			reportModeration(resp, p.watchModeration(ctx, cfg, pushed))
+		}
+		return resp, nil
+	case plugin.HookOnSuccess:
+		defer p.endRelease()
+		return p.publishSummaryResponse(), nil
+	case plugin.HookOnError:
+		defer p.endRelease()
+		if cfg.Rollback == RollbackUnlist {
+			return p.rollback(ctx, cfg, req.DryRun), nil
+		}
+	}
+
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Hook %s not handled", req.Hook),
+	}, nil
+}
+
+// resolvePackagePath normalizes the release version and substitutes release
+// placeholders in the configured package path. It returns the resolved path
+// and the Chocolatey package version.
+func (p *ChocolateyPlugin) resolvePackagePath(cfg *Config, releaseCtx plugin.ReleaseContext) (string, string, error) {
+	version, err := p.packageVersion(cfg, releaseCtx)
+	if err != nil {
+		return "", "", err
+	}
+	return substitutePlaceholders(cfg.PackagePath, version, releaseCtx.TagName), version, nil
+}
+
+// packageVersion returns the Chocolatey version for the release.
+func (p *ChocolateyPlugin) packageVersion(cfg *Config, releaseCtx plugin.ReleaseContext) (string, error) {
+	raw := releaseCtx.Version
+	if cfg.Version != "" {
+		raw = cfg.Version
+	}
+	opts := versionOptions{FixMode: cfg.PackageFixMode, FixCounter: cfg.PackageFixCounter}
+	if cfg.PackageFixMode == PackageFixDate {
+		opts.Now = p.fixDate()
+	}
+	return normalizeVersion(raw, opts)
+}
+
+// prePublish checks the nuspec against the community repository rules and
+// builds the package from it before publishing.
+func (p *ChocolateyPlugin) prePublish(cfg *Config, releaseCtx plugin.ReleaseContext, dryRun bool) (*plugin.ExecuteResponse, error) {
+	packagePath, version, err := p.resolvePackagePath(cfg, releaseCtx)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("invalid version: %v", err),
+		}, nil
+	}
+
+	// Validate package path.
+	if cfg.Pack {
+		if err := validatePackagePath(packagePath); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Error:   fmt.Sprintf("invalid package path: %v", err),
+			}, nil
+		}
+	}
+
+	// Validate nuspec path.
+	if err := validateNuspecPath(cfg.NuspecPath); err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("invalid nuspec path: %v", err),
+		}, nil
+	}
+
+	opts, err := releasePackOptions(cfg, releaseCtx, version)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("pack failed: %v", err),
+		}, nil
+	}
+
+	// Check the final manifest against the community repository rules.
+	violations := []ruleViolation{}
+	if cfg.ValidateNuspec {
+		_, spec, err := buildManifest(cfg.NuspecPath, opts)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Error:   fmt.Sprintf("nuspec validation failed: %v", err),
+			}, nil
+		}
+
+		violations = runRules(spec.Metadata, cfg.DisabledRules)
+		if failed := requirementViolations(violations); len(failed) > 0 {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Error:   fmt.Sprintf("nuspec violates %d community repository requirement(s): %s", len(failed), strings.Join(failed, ", ")),
+				Outputs: map[string]any{
+					"nuspec_path":     cfg.NuspecPath,
+					"rule_violations": violations,
+				},
+			}, nil
+		}
+	}
+
+	if !cfg.Pack {
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("Nuspec passed community repository requirements with %d other finding(s)", len(violations)),
+			Outputs: map[string]any{
+				"nuspec_path":     cfg.NuspecPath,
+				"rule_violations": violations,
+			},
+		}, nil
+	}
+
+	if dryRun {
 		return &plugin.ExecuteResponse{
 			Success: true,
-			Message: fmt.Sprintf("Hook %s not handled", req.Hook),
+			Message: "Would pack Chocolatey package",
+			Outputs: map[string]any{
+				"nuspec_path":     cfg.NuspecPath,
+				"package_path":    packagePath,
+				"version":         version,
+				"rule_violations": violations,
+			},
 		}, nil
 	}
+
+	result, err := packNuspec(cfg.NuspecPath, packagePath, opts)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("pack failed: %v", err),
+		}, nil
+	}
+
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Packed Chocolatey package %s %s", result.ID, result.Version),
+		Outputs: map[string]any{
+			"nuspec_path":         cfg.NuspecPath,
+			"package_path":        result.Path,
+			"package_id":          result.ID,
+			"version":             result.Version,
+			"files":               result.Files,
+			"pinned_dependencies": result.Pinned,
+			"rule_violations":     violations,
+		},
+		Artifacts: []plugin.Artifact{
+			{
+				Name:     filepath.Base(result.Path),
+				Path:     result.Path,
+				Type:     "file",
+				Size:     result.Size,
+				Checksum: result.Checksum,
+			},
+		},
+	}, nil
+}
+
+// releasePackOptions returns the options used to build the nuspec of the
+// release, including its release notes.
+func releasePackOptions(cfg *Config, releaseCtx plugin.ReleaseContext, version string) (packOptions, error) {
+	opts := packOptions{
+		Version:         version,
+		PinDependencies: cfg.PinDependencies,
+		Siblings:        cfg.SiblingPackages,
+	}
+	if cfg.Template {
+		opts.Template = newTemplateData(releaseCtx, version, cfg.Vars)
+	}
+
+	releaseNotes, err := buildReleaseNotes(cfg.ReleaseNotesMode, releaseCtx.ReleaseNotes, releaseNotesURL(cfg, releaseCtx))
+	if err != nil {
+		return packOptions{}, err
+	}
+	opts.ReleaseNotes = releaseNotes
+	return opts, nil
 }
 
 // pushPackage executes the choco push command.
 func (p *ChocolateyPlugin) pushPackage(ctx context.Context, cfg *Config, releaseCtx plugin.ReleaseContext, dryRun bool) (*plugin.ExecuteResponse, error) {
-	// Resolve version placeholder in package path.
-	version := strings.TrimPrefix(releaseCtx.Version, "v")
-	packagePath := strings.ReplaceAll(cfg.PackagePath, "{{version}}", version)
-	packagePath = strings.ReplaceAll(packagePath, "{{tag}}", releaseCtx.TagName)
+	version, err := p.packageVersion(cfg, releaseCtx)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("invalid version: %v", err),
+		}, nil
+	}
 
-	// Validate package path.
-	if err := validatePackagePath(packagePath); err != nil {
+	// Resolve placeholders and globs in the package paths.
+	packagePaths, err := expandPackagePaths(cfg.PackagePaths, version, releaseCtx.TagName)
+	if err != nil {
 		return &plugin.ExecuteResponse{
 			Success: false,
 			Error:   fmt.Sprintf("invalid package path: %v", err),
 		}, nil
 	}
+	if len(packagePaths) == 0 {
+		packagePaths = []string{""}
+	}
+
+	// Validate every package path before pushing any of them.
+	for _, packagePath := range packagePaths {
+		if err := validatePackagePath(packagePath); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Error:   fmt.Sprintf("invalid package path: %v", err),
+			}, nil
+		}
+	}
+
+	// Validate extra_args and env, which reach the choco command line.
+	if err := validateExtraArgs(cfg.ExtraArgs); err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("invalid extra_args: %v", err),
+		}, nil
+	}
+	if err := validateChocoEnv(cfg.Env); err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("invalid env: %v", err),
+		}, nil
+	}
+
+	if len(packagePaths) == 1 {
+		return p.pushPackageFile(ctx, cfg, packagePaths[0], version, dryRun)
+	}
+
+	// Push dependencies before the packages that depend on them.
+	ordered, dependsOn, err := orderByDependencies(packagePaths, version)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("cannot order packages: %v", err),
+		}, nil
+	}
+
+	// A package is never pushed when one of its dependencies failed.
+	results := make([]packageResult, 0, len(ordered))
+	failed := make(map[string]bool)
+	var failedPaths []string
+	var errorCode string
+	for _, packagePath := range ordered {
+		if dep := firstFailed(dependsOn[packagePath], failed); dep != "" {
+			failed[packagePath] = true
+			failedPaths = append(failedPaths, packagePath)
+			results = append(results, packageResult{
+				PackagePath: packagePath,
+				Success:     false,
+				Error:       fmt.Sprintf("not pushed: dependency %s failed", dep),
+			})
+			continue
+		}
+
+		resp, err := p.pushPackageFile(ctx, cfg, packagePath, version, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, packageResult{
+			PackagePath: packagePath,
+			Success:     resp.Success,
+			Message:     resp.Message,
+			Error:       resp.Error,
+			Outputs:     resp.Outputs,
+		})
+		if !resp.Success {
+			failed[packagePath] = true
+			failedPaths = append(failedPaths, packagePath)
+			if errorCode == "" {
+				errorCode, _ = resp.Outputs["error_code"].(string)
+			}
+		}
+	}
+
+	outputs := map[string]any{
+		"package_paths": ordered,
+		"version":       version,
+		"packages":      results,
+	}
+	if len(failedPaths) > 0 {
+		setErrorCode(outputs, errorCode)
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("push failed for %d of %d package(s): %s", len(failedPaths), len(ordered), strings.Join(failedPaths, ", ")),
+			Outputs: outputs,
+		}, nil
+	}
+
+	message := fmt.Sprintf("Successfully pushed %d Chocolatey packages", len(results))
+	if dryRun {
+		message = fmt.Sprintf("Would push %d Chocolatey packages", len(results))
+	}
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: message,
+		Outputs: outputs,
+	}, nil
+}
+
+// firstFailed returns the first of deps marked in failed, or "".
+func firstFailed(deps []string, failed map[string]bool) string {
+	for _, dep := range deps {
+		if failed[dep] {
+			return dep
+		}
+	}
+	return ""
+}
+
+// pushPackageFile pushes a single resolved package to the configured sources.
+func (p *ChocolateyPlugin) pushPackageFile(ctx context.Context, cfg *Config, packagePath, version string, dryRun bool) (*plugin.ExecuteResponse, error) {
+	// Push to each configured source.
+	if len(cfg.Sources) > 0 {
+		return p.pushToSources(ctx, cfg, packagePath, version, dryRun)
+	}
 
 	// Validate source URL.
 	if err := validateSourceURL(cfg.Source); err != nil {
@@ -122,8 +694,20 @@ func (p *ChocolateyPlugin) pushPackage(ctx context.Context, cfg *Config, release
 		}, nil
 	}
 
+	// Resolve the API key from its source only when actually pushing.
+	if cfg.APIKeySource != "" && !dryRun && cfg.APIKey == "" {
+		apiKey, err := p.resolveAPIKey(ctx, cfg.APIKeySource)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		cfg.APIKey = apiKey
+	}
+
 	// Validate API key is present.
-	if cfg.APIKey == "" {
+	if cfg.APIKey == "" && cfg.APIKeySource == "" {
 		return &plugin.ExecuteResponse{
 			Success: false,
 			Error:   "API key is required: set api_key in config or CHOCOLATEY_API_KEY environment variable",
@@ -138,25 +722,46 @@ func (p *ChocolateyPlugin) pushPackage(ctx context.Context, cfg *Config, release
 				"package_path": packagePath,
 				"source":       cfg.Source,
 				"version":      version,
-				"force":        cfg.Force,
+				"on_exists":    cfg.OnExists,
 				"timeout":      cfg.Timeout,
+				"push_method":  cfg.PushMethod,
 			},
 		}, nil
 	}
 
-	// Build command arguments.
-	args := p.buildPushArgs(cfg, packagePath)
+	// Inspect package contents before pushing.
+	if resp := inspectBeforePush(cfg, packagePath, version); resp != nil {
+		return resp, nil
+	}
 
-	// Create context with timeout.
-	execCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
-	defer cancel()
+	result := p.pushToSource(ctx, cfg, SourceConfig{URL: cfg.Source, APIKey: cfg.APIKey, Timeout: cfg.Timeout}, packagePath, version)
+	if !result.Success {
+		resp := &plugin.ExecuteResponse{
+			Success: false,
+			Error:   result.Error,
+		}
+		resp.Outputs = map[string]any{
+			"package_path": packagePath,
+			"source":       cfg.Source,
+			"version":      version,
+		}
+		setErrorCode(resp.Outputs, result.ErrorCode)
+		if len(result.Attempts) > 0 {
+			resp.Outputs["attempts"] = result.Attempts
+		}
+		return resp, nil
+	}
 
-	// Execute choco push.
-	output, err := p.getExecutor().Run(execCtx, "choco", args...)
-	if err != nil {
+	if result.Outcome == OutcomeSkipped {
 		return &plugin.ExecuteResponse{
-			Success: false,
-			Error:   fmt.Sprintf("choco push failed: %v\nOutput: %s", err, string(output)),
+			Success: true,
+			Message: fmt.Sprintf("Chocolatey package %s already exists on %s, skipping push", packagePath, feedURL(cfg)),
+			Outputs: map[string]any{
+				"package_path": packagePath,
+				"source":       cfg.Source,
+				"version":      version,
+				"outcome":      result.Outcome,
+			},
 		}, nil
 	}
 
@@ -167,17 +772,65 @@ func (p *ChocolateyPlugin) pushPackage(ctx context.Context, cfg *Config, release
 			"package_path": packagePath,
 			"source":       cfg.Source,
 			"version":      version,
-			"output":       string(output),
+			"outcome":      result.Outcome,
+			"output":       result.Output,
+			"attempts":     result.Attempts,
 		},
 	}, nil
 }
 
-// buildPushArgs constructs the command line arguments for choco push.
+// inspectBeforePush inspects the package when enabled and returns a failure
+// response if it has issues, or nil if the push may proceed.
+func inspectBeforePush(cfg *Config, packagePath, version string) *plugin.ExecuteResponse {
+	if !cfg.Inspect {
+		return nil
+	}
+	if _, issues := inspectPackage(packagePath, packageIDFromPath(packagePath, version), version); len(issues) > 0 {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("package inspection failed with %d issue(s)", len(issues)),
+			Outputs: map[string]any{
+				"package_path":      packagePath,
+				"version":           version,
+				"inspection_issues": issues,
+			},
+		}
+	}
+	return nil
+}
+
+// packageExists queries the feed for the package id and version.
+func (p *ChocolateyPlugin) packageExists(ctx context.Context, cfg *Config, packagePath, version string) (bool, error) {
+	id, err := packageID(packagePath, version)
+	if err != nil {
+		return false, err
+	}
+	return p.getNuGetClient().PackageExists(ctx, feedURL(cfg), id, version)
+}
+
+// packageID returns the id of a package, taken from its filename and falling
+// back to the embedded nuspec.
+func packageID(packagePath, version string) (string, error) {
+	if id := packageIDFromPath(packagePath, version); id != "" {
+		return id, nil
+	}
+	spec, err := readPackageNuspec(packagePath)
+	if err != nil {
+		return "", fmt.Errorf("cannot determine package id: %w", err)
+	}
+	return spec.Metadata.ID, nil
+}
+
+// buildPushArgs constructs the command line arguments for choco push. choco
+// accepts the API key only as an argument; in a container it is appended
+// from the environment by containerScript instead.
 func (p *ChocolateyPlugin) buildPushArgs(cfg *Config, packagePath string) []string {
 	args := []string{"push", packagePath}
 
 	// API key.
-	args = append(args, "--api-key", cfg.APIKey)
+	if cfg.Executor != ExecutorDocker {
+		args = append(args, "--api-key", cfg.APIKey)
+	}
 
 	// Source URL.
 	args = append(args, "--source", cfg.Source)
@@ -186,10 +839,13 @@ func (p *ChocolateyPlugin) buildPushArgs(cfg *Config, packagePath string) []stri
 	args = append(args, "--timeout", fmt.Sprintf("%d", cfg.Timeout))
 
 	// Force flag.
-	if cfg.Force {
+	if cfg.OnExists == OnExistsForce {
 		args = append(args, "--force")
 	}
 
+	// Allowlisted extra options.
+	args = append(args, cfg.ExtraArgs...)
+
 	return args
 }
 
@@ -226,6 +882,36 @@ func validatePackagePath(path string) error {
 	return nil
 }
 
+// validateNuspecPath validates a nuspec path to prevent path traversal and injection.
+func validateNuspecPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("nuspec path cannot be empty")
+	}
+
+	// Check length.
+	if len(path) > 512 {
+		return fmt.Errorf("nuspec path too long (max 512 characters)")
+	}
+
+	// Check for path traversal attempts.
+	cleaned := filepath.Clean(path)
+	if strings.HasPrefix(cleaned, "..") || strings.Contains(cleaned, string(filepath.Separator)+"..") {
+		return fmt.Errorf("path traversal detected: cannot use '..' to escape working directory")
+	}
+
+	// Must end with .nuspec.
+	if !strings.HasSuffix(strings.ToLower(path), ".nuspec") {
+		return fmt.Errorf("nuspec path must end with .nuspec")
+	}
+
+	// Basic pattern validation for the filename part.
+	if !nuspecPathPattern.MatchString(filepath.Base(path)) {
+		return fmt.Errorf("invalid nuspec filename: contains disallowed characters")
+	}
+
+	return nil
+}
+
 // validateSourceURL validates that a source URL is safe (SSRF protection).
 func validateSourceURL(rawURL string) error {
 	if rawURL == "" {
@@ -316,20 +1002,38 @@ func (p *ChocolateyPlugin) Validate(_ context.Context, config map[string]any) (*
 	parser := helpers.NewConfigParser(config)
 
 	// Validate package_path.
-	packagePath := parser.GetString("package_path", "", "")
-	if packagePath == "" {
+	packagePaths := packagePathPatterns(parser)
+	if len(packagePaths) == 0 {
 		vb.AddError("package_path", "package path is required")
-	} else {
-		// Only validate static parts (skip template validation).
-		if !strings.Contains(packagePath, "{{") {
-			if err := validatePackagePath(packagePath); err != nil {
-				vb.AddError("package_path", err.Error())
-			}
-		} else {
+	}
+	for _, packagePath := range packagePaths {
+		switch {
+		case strings.Contains(packagePath, "{{"):
 			// Check basic format even with templates.
 			if !strings.HasSuffix(strings.ToLower(packagePath), ".nupkg") {
 				vb.AddError("package_path", "package path must end with .nupkg")
 			}
+		case isGlobPattern(packagePath):
+			// Matches are validated individually once expanded.
+			if err := validatePackagePattern(packagePath); err != nil {
+				vb.AddError("package_path", err.Error())
+			}
+		default:
+			if err := validatePackagePath(packagePath); err != nil {
+				vb.AddError("package_path", err.Error())
+			}
+		}
+	}
+	if parser.GetBool("pack", false) && (len(packagePaths) > 1 || len(packagePaths) == 1 && isGlobPattern(packagePaths[0])) {
+		vb.AddError("package_path", "pack requires a single package path without globs")
+	}
+
+	// Validate api_key_source.
+	if apiKeySource := parser.GetString("api_key_source", "", ""); apiKeySource != "" {
+		if _, _, err := parseAPIKeySource(apiKeySource); err != nil {
+			vb.AddError("api_key_source", err.Error())
+		} else if parser.GetString("api_key", "", "") != "" {
+			vb.AddError("api_key_source", "api_key and api_key_source are mutually exclusive")
 		}
 	}
 
@@ -341,6 +1045,133 @@ func (p *ChocolateyPlugin) Validate(_ context.Context, config map[string]any) (*
 		}
 	}
 
+	// Validate nuspec_path when packing or validating.
+	nuspecPath := parser.GetString("nuspec_path", "", "")
+	if parser.GetBool("pack", false) && nuspecPath == "" {
+		vb.AddError("nuspec_path", "nuspec path is required when pack is enabled")
+	} else if parser.GetBool("validate_nuspec", false) && nuspecPath == "" {
+		vb.AddError("nuspec_path", "nuspec path is required when validate_nuspec is enabled")
+	} else if nuspecPath != "" {
+		if err := validateNuspecPath(nuspecPath); err != nil {
+			vb.AddError("nuspec_path", err.Error())
+		} else if parser.GetBool("template", false) {
+			// Render templates with placeholder data to catch unknown placeholders.
+			for _, err := range checkTemplates(nuspecPath, parseVars(parser.GetMap("vars"))) {
+				vb.AddError("template", err.Error())
+			}
+		}
+	}
+
+	// Validate disabled rule IDs.
+	if unknown := unknownRuleIDs(parser.GetStringSlice("disabled_rules", nil)); len(unknown) > 0 {
+		vb.AddError("disabled_rules", fmt.Sprintf("unknown rule IDs: %s", strings.Join(unknown, ", ")))
+	}
+
+	// Validate release notes mode.
+	switch parser.GetString("release_notes_mode", "", ReleaseNotesInline) {
+	case ReleaseNotesInline, ReleaseNotesLink, ReleaseNotesNone:
+	default:
+		vb.AddError("release_notes_mode", fmt.Sprintf("release notes mode must be one of: %s, %s, %s", ReleaseNotesInline, ReleaseNotesLink, ReleaseNotesNone))
+	}
+
+	// Validate package fix version and any version override.
+	versionOpts := versionOptions{
+		FixMode:    parser.GetString("package_fix_version", "", PackageFixNone),
+		FixCounter: parser.GetInt("package_fix_counter", 0),
+		Now:        p.clock(),
+	}
+	switch versionOpts.FixMode {
+	case PackageFixNone, PackageFixDate:
+	case PackageFixCounter:
+		if versionOpts.FixCounter <= 0 {
+			vb.AddError("package_fix_counter", "package fix counter must be a positive integer when package_fix_version is counter")
+			versionOpts.FixMode = PackageFixNone
+		}
+	default:
+		vb.AddError("package_fix_version", fmt.Sprintf("package fix version must be one of: %s, %s, %s", PackageFixNone, PackageFixDate, PackageFixCounter))
+		versionOpts.FixMode = PackageFixNone
+	}
+	if version := parser.GetString("version", "", ""); version != "" {
+		if _, err := normalizeVersion(version, versionOpts); err != nil {
+			vb.AddError("version", err.Error())
+		}
+	}
+
+	// Validate on_exists and the feed it queries.
+	switch parser.GetString("on_exists", "", OnExistsFail) {
+	case OnExistsFail, OnExistsSkip, OnExistsForce:
+	default:
+		vb.AddError("on_exists", fmt.Sprintf("on_exists must be one of: %s, %s, %s", OnExistsFail, OnExistsSkip, OnExistsForce))
+	}
+	if feed := parser.GetString("feed_url", "", ""); feed != "" {
+		if err := validateSourceURL(feed); err != nil {
+			vb.AddError("feed_url", err.Error())
+		}
+	}
+
+	// Validate sources.
+	if config["sources"] != nil {
+		validateSources(vb, config["sources"])
+	}
+	if parser.GetInt("max_parallel", defaultMaxParallel) <= 0 {
+		vb.AddError("max_parallel", "max_parallel must be a positive integer")
+	}
+
+	// Validate retry settings.
+	if retries := parser.GetInt("retries", 0); retries < 0 || retries > maxRetries {
+		vb.AddError("retries", fmt.Sprintf("retries must be between 0 and %d", maxRetries))
+	}
+	if parser.GetInt("retry_backoff", defaultRetryBackoff) <= 0 {
+		vb.AddError("retry_backoff", "retry_backoff must be a positive integer")
+	}
+
+	// Validate moderation polling.
+	if parser.GetInt("moderation_timeout", defaultModerationTimeout) <= 0 {
+		vb.AddError("moderation_timeout", "moderation_timeout must be a positive integer")
+	}
+	if parser.GetInt("moderation_poll_interval", defaultModerationPollInterval) <= 0 {
+		vb.AddError("moderation_poll_interval", "moderation_poll_interval must be a positive integer")
+	}
+
+	// Validate rollback.
+	switch parser.GetString("rollback", "", RollbackNone) {
+	case RollbackUnlist, RollbackNone:
+	default:
+		vb.AddError("rollback", fmt.Sprintf("rollback must be one of: %s, %s", RollbackUnlist, RollbackNone))
+	}
+
+	// Validate the choco preflight settings.
+	if minVersion := parser.GetString("min_choco_version", "", defaultMinChocoVersion); !minVersionPattern.MatchString(minVersion) {
+		vb.AddError("min_choco_version", "min_choco_version must be a numeric version such as 1.0.0")
+	}
+
+	// Validate extra_args and env.
+	if err := validateExtraArgs(parser.GetStringSlice("extra_args", nil)); err != nil {
+		vb.AddError("extra_args", err.Error())
+	}
+	for name := range parseVars(parser.GetMap("env")) {
+		if !envNamePattern.MatchString(name) {
+			vb.AddError("env", fmt.Sprintf("invalid environment variable name %q", name))
+		}
+	}
+
+	// Validate push method.
+	pushMethod := parser.GetString("push_method", "", PushMethodChoco)
+	if pushMethod != PushMethodChoco && pushMethod != PushMethodNative {
+		vb.AddError("push_method", fmt.Sprintf("push method must be one of: %s, %s", PushMethodChoco, PushMethodNative))
+	} else if pushMethod == PushMethodNative && (parser.GetString("on_exists", "", "") == OnExistsForce || parser.GetBool("force", false)) {
+		// The NuGet API rejects a version that exists; only choco push --force replaces it.
+		vb.AddError("on_exists", "on_exists force requires push_method choco")
+	}
+
+	// Validate executor.
+	switch executor := parser.GetString("executor", "", ExecutorLocal); {
+	case executor != ExecutorLocal && executor != ExecutorDocker:
+		vb.AddError("executor", fmt.Sprintf("executor must be one of: %s, %s", ExecutorLocal, ExecutorDocker))
+	case executor == ExecutorDocker && pushMethod == PushMethodNative:
+		vb.AddError("executor", "executor docker requires push_method choco")
+	}
+
 	// Validate timeout is positive.
 	timeout := parser.GetInt("timeout", 300)
 	if timeout <= 0 {
@@ -354,11 +1185,94 @@ func (p *ChocolateyPlugin) Validate(_ context.Context, config map[string]any) (*
 func (p *ChocolateyPlugin) parseConfig(raw map[string]any) *Config {
 	parser := helpers.NewConfigParser(raw)
 
+	// The deprecated force flag maps to on_exists: force.
+	onExists := OnExistsFail
+	if parser.GetBool("force", false) {
+		onExists = OnExistsForce
+	}
+
+	timeout := parser.GetInt("timeout", 300)
+
+	// An API key source replaces the CHOCOLATEY_API_KEY fallback.
+	apiKeySource := parser.GetString("api_key_source", "", "")
+	apiKeyEnv := "CHOCOLATEY_API_KEY"
+	if apiKeySource != "" {
+		apiKeyEnv = ""
+	}
+
+	// Pack builds a single package; Validate rejects several paths with pack.
+	packagePaths := packagePathPatterns(parser)
+	packagePath := ""
+	if len(packagePaths) > 0 {
+		packagePath = packagePaths[0]
+	}
+
 	return &Config{
-		APIKey:      parser.GetString("api_key", "CHOCOLATEY_API_KEY", ""),
-		Source:      parser.GetString("source", "", "https://push.chocolatey.org/"),
-		PackagePath: parser.GetString("package_path", "", ""),
-		Timeout:     parser.GetInt("timeout", 300),
-		Force:       parser.GetBool("force", false),
+		APIKey:       parser.GetString("api_key", apiKeyEnv, ""),
+		APIKeySource: apiKeySource,
+		Source:       parser.GetString("source", "", "https://push.chocolatey.org/"),
+		PackagePath:  packagePath,
+		PackagePaths: packagePaths,
+		Timeout:      timeout,
+		PushMethod:   parser.GetString("push_method", "", PushMethodChoco),
+
+		ChocoPath:        parser.GetString("choco_path", "", defaultChocoPath),
+		MinChocoVersion:  parser.GetString("min_choco_version", "", defaultMinChocoVersion),
+		ExtraArgs:        parser.GetStringSlice("extra_args", nil),
+		Env:              parseVars(parser.GetMap("env")),
+		Executor:         parser.GetString("executor", "", ExecutorLocal),
+		ContainerRuntime: parser.GetString("container_runtime", "", defaultContainerRuntime),
+		ContainerImage:   parser.GetString("container_image", "", defaultContainerImage),
+		NuspecPath:       parser.GetString("nuspec_path", "", ""),
+		Pack:             parser.GetBool("pack", false),
+		Template:         parser.GetBool("template", false),
+		Vars:             parseVars(parser.GetMap("vars")),
+
+		PinDependencies: parser.GetBool("pin_dependencies", false),
+		SiblingPackages: parser.GetStringSlice("sibling_packages", nil),
+
+		ReleaseNotesMode: parser.GetString("release_notes_mode", "", ReleaseNotesInline),
+		ReleaseNotesURL:  parser.GetString("release_notes_url", "", ""),
+
+		Version:           parser.GetString("version", "", ""),
+		PackageFixMode:    parser.GetString("package_fix_version", "", PackageFixNone),
+		PackageFixCounter: parser.GetInt("package_fix_counter", 0),
+
+		Inspect: parser.GetBool("inspect", false),
+
+		OnExists: parser.GetString("on_exists", "", onExists),
+		FeedURL:  parser.GetString("feed_url", "", ""),
+
+		Sources:     parseSources(raw["sources"], timeout),
+		MaxParallel: parser.GetInt("max_parallel", defaultMaxParallel),
+
+		Retries:      parser.GetInt("retries", 0),
+		RetryBackoff: parser.GetInt("retry_backoff", defaultRetryBackoff),
+
+		ValidateNuspec: parser.GetBool("validate_nuspec", false),
+		DisabledRules:  parser.GetStringSlice("disabled_rules", nil),
+
+		WatchModeration:        parser.GetBool("watch_moderation", false),
+		ModerationTimeout:      parser.GetInt("moderation_timeout", defaultModerationTimeout),
+		ModerationPollInterval: parser.GetInt("moderation_poll_interval", defaultModerationPollInterval),
+
+		Rollback: parser.GetString("rollback", "", RollbackNone),
+	}
+}
+
+// packagePathPatterns returns package_path as a list, accepting a single path or a list.
+func packagePathPatterns(parser *helpers.ConfigParser) []string {
+	if path := parser.GetString("package_path", "", ""); path != "" {
+		return []string{path}
+	}
+	return parser.GetStringSlice("package_path", nil)
+}
+
+// parseVars converts the vars config map into template string values.
+func parseVars(raw map[string]any) map[string]string {
+	vars := make(map[string]string, len(raw))
+	for k, v := range raw {
+		vars[k] = fmt.Sprint(v)
 	}
+	return vars
 }
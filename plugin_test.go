@@ -4,6 +4,8 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"strings"
 	"testing"
@@ -19,20 +21,60 @@ type MockCommandExecutor struct {
 	Output []byte
 	// Err is the error to return from Run.
 	Err error
+	// ErrOn, when set, limits Err to commands whose first argument matches.
+	ErrOn string
 }
 
+// discardLogger drops streamed choco output in tests that do not check it.
+var discardLogger = log.New(io.Discard, "", 0)
+
 // ExecutedCommand represents a recorded command execution.
 type ExecutedCommand struct {
 	Name string
 	Args []string
+	// Env holds the variables passed to RunEnv.
+	Env []string
 }
 
 // Run records the command and returns the configured output/error.
 func (m *MockCommandExecutor) Run(_ context.Context, name string, args ...string) ([]byte, error) {
 	m.Commands = append(m.Commands, ExecutedCommand{Name: name, Args: args})
+	if m.ErrOn != "" && (len(args) == 0 || args[0] != m.ErrOn) {
+		return m.Output, nil
+	}
 	return m.Output, m.Err
 }
 
+// RunEnv records the command with its environment and returns the configured output/error.
+func (m *MockCommandExecutor) RunEnv(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	output, err := m.Run(ctx, name, args...)
+	m.Commands[len(m.Commands)-1].Env = env
+	return output, err
+}
+
+// RunStream records the command like RunEnv and passes each line of the
+// configured output to onLine.
+func (m *MockCommandExecutor) RunStream(ctx context.Context, env []string, onLine func(line string), name string, args ...string) ([]byte, error) {
+	output, err := m.RunEnv(ctx, env, name, args...)
+	for _, line := range strings.Split(strings.TrimSuffix(string(output), "\n"), "\n") {
+		if line != "" {
+			onLine(line)
+		}
+	}
+	return output, err
+}
+
+// pushCommands returns the choco push commands among cmds.
+func pushCommands(cmds []ExecutedCommand) []ExecutedCommand {
+	var pushes []ExecutedCommand
+	for _, cmd := range cmds {
+		if len(cmd.Args) > 0 && cmd.Args[0] == "push" {
+			pushes = append(pushes, cmd)
+		}
+	}
+	return pushes
+}
+
 func TestGetInfo(t *testing.T) {
 	p := &ChocolateyPlugin{}
 	info := p.GetInfo()
@@ -185,6 +227,117 @@ func TestValidate(t *testing.T) {
 			},
 			wantValid: true,
 		},
+		{
+			name: "valid push_method native",
+			config: map[string]any{
+				"package_path": "mypackage.1.0.0.nupkg",
+				"source":       "http://localhost:8080/",
+				"push_method":  "native",
+			},
+			wantValid: true,
+		},
+		{
+			name: "invalid push_method",
+			config: map[string]any{
+				"package_path": "mypackage.1.0.0.nupkg",
+				"source":       "http://localhost:8080/",
+				"push_method":  "ftp",
+			},
+			wantValid:  false,
+			wantErrFld: "push_method",
+			wantErrMsg: "push method must be one of: choco, native",
+		},
+		{
+			name: "on_exists force with push_method native",
+			config: map[string]any{
+				"package_path": "mypackage.1.0.0.nupkg",
+				"source":       "http://localhost:8080/",
+				"push_method":  "native",
+				"on_exists":    "force",
+			},
+			wantValid:  false,
+			wantErrFld: "on_exists",
+			wantErrMsg: "on_exists force requires push_method choco",
+		},
+		{
+			name: "force with push_method native",
+			config: map[string]any{
+				"package_path": "mypackage.1.0.0.nupkg",
+				"source":       "http://localhost:8080/",
+				"push_method":  "native",
+				"force":        true,
+			},
+			wantValid:  false,
+			wantErrFld: "on_exists",
+			wantErrMsg: "on_exists force requires push_method choco",
+		},
+		{
+			name: "pack without nuspec_path",
+			config: map[string]any{
+				"package_path": "mypackage.1.0.0.nupkg",
+				"source":       "http://localhost:8080/",
+				"pack":         true,
+			},
+			wantValid:  false,
+			wantErrFld: "nuspec_path",
+			wantErrMsg: "nuspec path is required when pack is enabled",
+		},
+		{
+			name: "invalid nuspec_path",
+			config: map[string]any{
+				"package_path": "mypackage.1.0.0.nupkg",
+				"source":       "http://localhost:8080/",
+				"nuspec_path":  "mypackage.xml",
+				"pack":         true,
+			},
+			wantValid:  false,
+			wantErrFld: "nuspec_path",
+			wantErrMsg: "nuspec path must end with .nuspec",
+		},
+		{
+			name: "invalid release_notes_mode",
+			config: map[string]any{
+				"package_path":       "mypackage.1.0.0.nupkg",
+				"source":             "http://localhost:8080/",
+				"release_notes_mode": "full",
+			},
+			wantValid:  false,
+			wantErrFld: "release_notes_mode",
+			wantErrMsg: "release notes mode must be one of: inline, link, none",
+		},
+		{
+			name: "validate_nuspec without nuspec_path",
+			config: map[string]any{
+				"package_path":    "mypackage.1.0.0.nupkg",
+				"source":          "http://localhost:8080/",
+				"validate_nuspec": true,
+			},
+			wantValid:  false,
+			wantErrFld: "nuspec_path",
+			wantErrMsg: "nuspec path is required when validate_nuspec is enabled",
+		},
+		{
+			name: "invalid on_exists",
+			config: map[string]any{
+				"package_path": "mypackage.1.0.0.nupkg",
+				"source":       "http://localhost:8080/",
+				"on_exists":    "replace",
+			},
+			wantValid:  false,
+			wantErrFld: "on_exists",
+			wantErrMsg: "on_exists must be one of: fail, skip, force",
+		},
+		{
+			name: "unknown disabled rule",
+			config: map[string]any{
+				"package_path":   "mypackage.1.0.0.nupkg",
+				"source":         "http://localhost:8080/",
+				"disabled_rules": []any{"title-missing", "no-such-rule"},
+			},
+			wantValid:  false,
+			wantErrFld: "disabled_rules",
+			wantErrMsg: "unknown rule IDs: no-such-rule",
+		},
 		{
 			name: "invalid timeout - zero",
 			config: map[string]any{
@@ -259,7 +412,7 @@ func TestParseConfig(t *testing.T) {
 				Source:      "https://push.chocolatey.org/",
 				PackagePath: "",
 				Timeout:     300,
-				Force:       false,
+				OnExists:    OnExistsFail,
 			},
 		},
 		{
@@ -276,7 +429,7 @@ func TestParseConfig(t *testing.T) {
 				Source:      "https://custom.chocolatey.org/",
 				PackagePath: "mypackage.1.0.0.nupkg",
 				Timeout:     600,
-				Force:       true,
+				OnExists:    OnExistsForce,
 			},
 		},
 		{
@@ -290,7 +443,7 @@ func TestParseConfig(t *testing.T) {
 				Source:      "https://push.chocolatey.org/",
 				PackagePath: "",
 				Timeout:     300,
-				Force:       false,
+				OnExists:    OnExistsFail,
 			},
 		},
 		{
@@ -306,7 +459,7 @@ func TestParseConfig(t *testing.T) {
 				Source:      "https://push.chocolatey.org/",
 				PackagePath: "",
 				Timeout:     300,
-				Force:       false,
+				OnExists:    OnExistsFail,
 			},
 		},
 		{
@@ -320,7 +473,19 @@ func TestParseConfig(t *testing.T) {
 				Source:      "https://push.chocolatey.org/",
 				PackagePath: "mypackage.1.0.0.nupkg",
 				Timeout:     300,
-				Force:       true,
+				OnExists:    OnExistsForce,
+			},
+		},
+		{
+			name: "on_exists takes precedence over force",
+			config: map[string]any{
+				"force":     true,
+				"on_exists": "skip",
+			},
+			expected: Config{
+				Source:   "https://push.chocolatey.org/",
+				Timeout:  300,
+				OnExists: OnExistsSkip,
 			},
 		},
 	}
@@ -352,8 +517,8 @@ func TestParseConfig(t *testing.T) {
 			if cfg.Timeout != tt.expected.Timeout {
 				t.Errorf("Timeout: expected %d, got %d", tt.expected.Timeout, cfg.Timeout)
 			}
-			if cfg.Force != tt.expected.Force {
-				t.Errorf("Force: expected %v, got %v", tt.expected.Force, cfg.Force)
+			if cfg.OnExists != tt.expected.OnExists {
+				t.Errorf("OnExists: expected %s, got %s", tt.expected.OnExists, cfg.OnExists)
 			}
 		})
 	}
@@ -426,8 +591,8 @@ func TestExecuteDryRun(t *testing.T) {
 				if outputs["source"] != "http://localhost:8080/" {
 					t.Errorf("expected source 'http://localhost:8080/', got '%v'", outputs["source"])
 				}
-				if outputs["force"] != true {
-					t.Errorf("expected force true, got '%v'", outputs["force"])
+				if outputs["on_exists"] != OnExistsForce {
+					t.Errorf("expected on_exists force, got '%v'", outputs["on_exists"])
 				}
 				if outputs["timeout"] != 600 {
 					t.Errorf("expected timeout 600, got '%v'", outputs["timeout"])
@@ -475,6 +640,7 @@ func TestExecuteWithMockExecutor(t *testing.T) {
 		releaseCtx     plugin.ReleaseContext
 		mockOutput     []byte
 		mockErr        error
+		mockErrOn      string
 		expectedResult bool
 		expectedError  string
 		checkCommand   func(t *testing.T, cmds []ExecutedCommand)
@@ -493,6 +659,7 @@ func TestExecuteWithMockExecutor(t *testing.T) {
 			mockErr:        nil,
 			expectedResult: true,
 			checkCommand: func(t *testing.T, cmds []ExecutedCommand) {
+				cmds = pushCommands(cmds)
 				if len(cmds) != 1 {
 					t.Fatalf("expected 1 command, got %d", len(cmds))
 				}
@@ -525,6 +692,7 @@ func TestExecuteWithMockExecutor(t *testing.T) {
 			mockErr:        nil,
 			expectedResult: true,
 			checkCommand: func(t *testing.T, cmds []ExecutedCommand) {
+				cmds = pushCommands(cmds)
 				if len(cmds) != 1 {
 					t.Fatalf("expected 1 command, got %d", len(cmds))
 				}
@@ -555,6 +723,7 @@ func TestExecuteWithMockExecutor(t *testing.T) {
 			mockErr:        nil,
 			expectedResult: true,
 			checkCommand: func(t *testing.T, cmds []ExecutedCommand) {
+				cmds = pushCommands(cmds)
 				if len(cmds) != 1 {
 					t.Fatalf("expected 1 command, got %d", len(cmds))
 				}
@@ -586,6 +755,7 @@ func TestExecuteWithMockExecutor(t *testing.T) {
 			mockErr:        nil,
 			expectedResult: true,
 			checkCommand: func(t *testing.T, cmds []ExecutedCommand) {
+				cmds = pushCommands(cmds)
 				if len(cmds) != 1 {
 					t.Fatalf("expected 1 command, got %d", len(cmds))
 				}
@@ -606,9 +776,25 @@ func TestExecuteWithMockExecutor(t *testing.T) {
 			},
 			mockOutput:     []byte("Error: Package already exists"),
 			mockErr:        fmt.Errorf("exit status 1"),
+			mockErrOn:      "push",
 			expectedResult: false,
 			expectedError:  "choco push failed",
 		},
+		{
+			name: "api key redacted from push output",
+			config: map[string]any{
+				"package_path": "mypackage.1.0.0.nupkg",
+				"api_key":      "test-api-key",
+				"source":       "https://push.chocolatey.org/",
+			},
+			releaseCtx: plugin.ReleaseContext{
+				Version: "v1.0.0",
+			},
+			mockOutput:     []byte("Pushing with key test-api-key failed"),
+			mockErr:        fmt.Errorf("exit status 1"),
+			expectedResult: false,
+			expectedError:  "Output: Pushing with key [REDACTED] failed",
+		},
 		{
 			name: "missing api key",
 			config: map[string]any{
@@ -627,8 +813,9 @@ func TestExecuteWithMockExecutor(t *testing.T) {
 			mock := &MockCommandExecutor{
 				Output: tt.mockOutput,
 				Err:    tt.mockErr,
+				ErrOn:  tt.mockErrOn,
 			}
-			p := &ChocolateyPlugin{cmdExecutor: mock}
+			p := &ChocolateyPlugin{cmdExecutor: mock, logger: discardLogger}
 			ctx := context.Background()
 
 			req := plugin.ExecuteRequest{
@@ -666,15 +853,11 @@ func TestExecuteUnhandledHook(t *testing.T) {
 		{name: "PreInit hook", hook: plugin.HookPreInit},
 		{name: "PostInit hook", hook: plugin.HookPostInit},
 		{name: "PrePlan hook", hook: plugin.HookPrePlan},
-		{name: "PostPlan hook", hook: plugin.HookPostPlan},
 		{name: "PreVersion hook", hook: plugin.HookPreVersion},
 		{name: "PostVersion hook", hook: plugin.HookPostVersion},
 		{name: "PreNotes hook", hook: plugin.HookPreNotes},
 		{name: "PostNotes hook", hook: plugin.HookPostNotes},
-		{name: "PreApprove hook", hook: plugin.HookPreApprove},
 		{name: "PostApprove hook", hook: plugin.HookPostApprove},
-		{name: "PrePublish hook", hook: plugin.HookPrePublish},
-		{name: "OnSuccess hook", hook: plugin.HookOnSuccess},
 		{name: "OnError hook", hook: plugin.HookOnError},
 	}
 
@@ -934,10 +1117,10 @@ func TestBuildPushArgs(t *testing.T) {
 		{
 			name: "basic args",
 			cfg: &Config{
-				APIKey:  "test-key",
-				Source:  "https://push.chocolatey.org/",
-				Timeout: 300,
-				Force:   false,
+				APIKey:   "test-key",
+				Source:   "https://push.chocolatey.org/",
+				Timeout:  300,
+				OnExists: OnExistsFail,
 			},
 			packagePath: "mypackage.1.0.0.nupkg",
 			expected:    []string{"push", "mypackage.1.0.0.nupkg", "--api-key", "test-key", "--source", "https://push.chocolatey.org/", "--timeout", "300"},
@@ -945,10 +1128,10 @@ func TestBuildPushArgs(t *testing.T) {
 		{
 			name: "with force flag",
 			cfg: &Config{
-				APIKey:  "test-key",
-				Source:  "https://push.chocolatey.org/",
-				Timeout: 300,
-				Force:   true,
+				APIKey:   "test-key",
+				Source:   "https://push.chocolatey.org/",
+				Timeout:  300,
+				OnExists: OnExistsForce,
 			},
 			packagePath: "mypackage.1.0.0.nupkg",
 			expected:    []string{"push", "mypackage.1.0.0.nupkg", "--api-key", "test-key", "--source", "https://push.chocolatey.org/", "--timeout", "300", "--force"},
@@ -956,14 +1139,26 @@ func TestBuildPushArgs(t *testing.T) {
 		{
 			name: "custom timeout",
 			cfg: &Config{
-				APIKey:  "test-key",
-				Source:  "https://custom.org/",
-				Timeout: 600,
-				Force:   false,
+				APIKey:   "test-key",
+				Source:   "https://custom.org/",
+				Timeout:  600,
+				OnExists: OnExistsFail,
 			},
 			packagePath: "mypackage.1.0.0.nupkg",
 			expected:    []string{"push", "mypackage.1.0.0.nupkg", "--api-key", "test-key", "--source", "https://custom.org/", "--timeout", "600"},
 		},
+		{
+			name: "in a container",
+			cfg: &Config{
+				APIKey:   "test-key",
+				Source:   "https://push.chocolatey.org/",
+				Timeout:  300,
+				OnExists: OnExistsFail,
+				Executor: ExecutorDocker,
+			},
+			packagePath: "mypackage.1.0.0.nupkg",
+			expected:    []string{"push", "mypackage.1.0.0.nupkg", "--source", "https://push.chocolatey.org/", "--timeout", "300"},
+		},
 	}
 
 	p := &ChocolateyPlugin{}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// redactedSecret replaces secrets in errors and outputs.
+const redactedSecret = "[REDACTED]"
+
+// proxyEnvVars are the environment variables that may carry proxy
+// credentials used by choco and the native HTTP client.
+var proxyEnvVars = []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "ALL_PROXY", "all_proxy"}
+
+// redactor masks every configured secret in plugin responses.
+type redactor struct {
+	secrets []string
+}
+
+// newRedactor collects the secrets known for cfg: the API keys, passwords
+// embedded in source and feed URLs, and proxy credentials from the
+// environment, env and extra_args.
+func newRedactor(cfg *Config) *redactor {
+	r := &redactor{}
+	r.add(cfg.APIKey)
+	r.addURL(cfg.Source)
+	r.addURL(cfg.FeedURL)
+	for _, src := range cfg.Sources {
+		r.add(src.APIKey)
+		r.addURL(src.URL)
+	}
+	for _, name := range proxyEnvVars {
+		r.addURL(os.Getenv(name))
+		r.addURL(cfg.Env[name])
+	}
+	for _, proxy := range extraArgValues(cfg.ExtraArgs, "--proxy") {
+		r.addURL(proxy)
+	}
+	for _, password := range extraArgValues(cfg.ExtraArgs, "--proxy-password") {
+		r.add(password)
+	}
+
+	// Replace longer secrets first so a secret containing another is fully masked.
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+	return r
+}
+
+// add registers a secret, ignoring empty values and duplicates.
+func (r *redactor) add(secret string) {
+	if secret == "" || containsString(r.secrets, secret) {
+		return
+	}
+	r.secrets = append(r.secrets, secret)
+}
+
+// addURL registers the password of a URL with user info, in both its raw
+// and escaped forms.
+func (r *redactor) addURL(rawURL string) {
+	if rawURL == "" {
+		return
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return
+	}
+	password, ok := u.User.Password()
+	if !ok {
+		return
+	}
+	r.add(password)
+	if _, escaped, ok := strings.Cut(u.User.String(), ":"); ok {
+		r.add(escaped)
+	}
+	r.add(url.QueryEscape(password))
+}
+
+// String masks every secret in s.
+func (r *redactor) String(s string) string {
+	return redactSecrets(s, r.secrets...)
+}
+
+// Response masks secrets in the message, error, outputs and artifacts of resp.
+func (r *redactor) Response(resp *plugin.ExecuteResponse) *plugin.ExecuteResponse {
+	if resp == nil || len(r.secrets) == 0 {
+		return resp
+	}
+	return r.value(reflect.ValueOf(resp)).Interface().(*plugin.ExecuteResponse)
+}
+
+// value returns a copy of v with secrets masked in every string it contains.
+// Output values keep their types so callers can still type-assert them.
+func (r *redactor) value(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.String:
+		out := reflect.New(v.Type()).Elem()
+		out.SetString(r.String(v.String()))
+		return out
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		if v.Kind() == reflect.Pointer {
+			out := reflect.New(v.Type().Elem())
+			out.Elem().Set(r.value(v.Elem()))
+			return out
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(r.value(v.Elem()))
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return reflect.ValueOf([]byte(r.String(string(v.Bytes())))).Convert(v.Type())
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(r.value(v.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(r.value(v.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(r.value(iter.Key()), r.value(iter.Value()))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if out.Field(i).CanSet() {
+				out.Field(i).Set(r.value(v.Field(i)))
+			}
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// redactSecrets replaces every occurrence of the given secrets in s.
+func redactSecrets(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redactedSecret)
+		}
+	}
+	return s
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Release notes modes.
+const (
+	// ReleaseNotesInline writes the generated release notes into the nuspec.
+	ReleaseNotesInline = "inline"
+	// ReleaseNotesLink writes a link to the full release notes into the nuspec.
+	ReleaseNotesLink = "link"
+	// ReleaseNotesNone leaves the nuspec <releaseNotes> element untouched.
+	ReleaseNotesNone = "none"
+)
+
+// maxReleaseNotesLength is the longest <releaseNotes> value the gallery accepts.
+const maxReleaseNotesLength = 4000
+
+// releaseNotesURL returns the link to the full release notes, preferring the
+// configured URL over one derived from the repository and tag.
+func releaseNotesURL(cfg *Config, releaseCtx plugin.ReleaseContext) string {
+	if cfg.ReleaseNotesURL != "" {
+		return cfg.ReleaseNotesURL
+	}
+	if releaseCtx.RepositoryURL == "" || releaseCtx.TagName == "" {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimRight(releaseCtx.RepositoryURL, "/"), ".git") + "/releases/tag/" + releaseCtx.TagName
+}
+
+// buildReleaseNotes returns the <releaseNotes> value for the given mode.
+// An empty result means the nuspec should be left as is.
+func buildReleaseNotes(mode, notes, link string) (string, error) {
+	switch mode {
+	case ReleaseNotesNone:
+		return "", nil
+	case ReleaseNotesLink:
+		if link == "" {
+			return "", fmt.Errorf("release_notes_mode %q requires release_notes_url or a repository URL and tag", ReleaseNotesLink)
+		}
+		return link, nil
+	default:
+		notes = strings.TrimSpace(notes)
+		if notes == "" {
+			return "", nil
+		}
+		return truncateReleaseNotes(notes, link, maxReleaseNotesLength), nil
+	}
+}
+
+// truncateReleaseNotes shortens notes to limit characters, ending with a link
+// to the full notes when one is available.
+func truncateReleaseNotes(notes, link string, limit int) string {
+	if utf8.RuneCountInString(notes) <= limit {
+		return notes
+	}
+
+	suffix := "\n\n..."
+	if link != "" {
+		suffix = "\n\n... Full release notes: " + link
+	}
+
+	keep := limit - utf8.RuneCountInString(suffix)
+	if keep < 0 {
+		keep = 0
+	}
+	runes := []rune(notes)
+	return strings.TrimRight(string(runes[:keep]), " \t\n") + suffix
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestBuildReleaseNotes(t *testing.T) {
+	long := strings.Repeat("Fixed a bug. ", 500)
+
+	tests := []struct {
+		name      string
+		mode      string
+		notes     string
+		link      string
+		expected  string
+		check     func(t *testing.T, got string)
+		errSubstr string
+	}{
+		{name: "inline", mode: ReleaseNotesInline, notes: "## Fixes\n- bug\n", expected: "## Fixes\n- bug"},
+		{name: "inline empty notes", mode: ReleaseNotesInline, notes: "  ", expected: ""},
+		{name: "link", mode: ReleaseNotesLink, notes: "ignored", link: "https://example.com/v1", expected: "https://example.com/v1"},
+		{name: "link without url", mode: ReleaseNotesLink, errSubstr: "requires release_notes_url"},
+		{name: "none", mode: ReleaseNotesNone, notes: "ignored", expected: ""},
+		{
+			name:  "inline truncated with link",
+			mode:  ReleaseNotesInline,
+			notes: long,
+			link:  "https://example.com/v1",
+			check: func(t *testing.T, got string) {
+				if n := utf8.RuneCountInString(got); n > maxReleaseNotesLength {
+					t.Errorf("expected at most %d characters, got %d", maxReleaseNotesLength, n)
+				}
+				if !strings.HasSuffix(got, "... Full release notes: https://example.com/v1") {
+					t.Errorf("expected link suffix, got %q", got[len(got)-80:])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildReleaseNotes(tt.mode, tt.notes, tt.link)
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Errorf("expected error containing '%s', got %v", tt.errSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+				return
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTruncateReleaseNotesMultibyte(t *testing.T) {
+	got := truncateReleaseNotes(strings.Repeat("ü", 50), "", 20)
+	if !utf8.ValidString(got) {
+		t.Error("expected valid UTF-8 after truncation")
+	}
+	if n := utf8.RuneCountInString(got); n != 20 {
+		t.Errorf("expected 20 characters, got %d", n)
+	}
+}
+
+func TestReleaseNotesURL(t *testing.T) {
+	releaseCtx := plugin.ReleaseContext{RepositoryURL: "https://github.com/relicta-tech/mypackage.git", TagName: "v1.0.0"}
+
+	if got := releaseNotesURL(&Config{}, releaseCtx); got != "https://github.com/relicta-tech/mypackage/releases/tag/v1.0.0" {
+		t.Errorf("unexpected derived URL: %s", got)
+	}
+	if got := releaseNotesURL(&Config{ReleaseNotesURL: "https://example.com/notes"}, releaseCtx); got != "https://example.com/notes" {
+		t.Errorf("expected configured URL, got %s", got)
+	}
+	if got := releaseNotesURL(&Config{}, plugin.ReleaseContext{}); got != "" {
+		t.Errorf("expected empty URL without repository, got %s", got)
+	}
+}
+
+func TestExecutePackInjectsReleaseNotes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestNuspec(t, dir, testNuspec, nil)
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	releaseCtx := plugin.ReleaseContext{
+		Version:       "v1.0.0",
+		TagName:       "v1.0.0",
+		RepositoryURL: "https://github.com/relicta-tech/mypackage",
+		ReleaseNotes:  "### Fixes\n- Handle <paths> & quotes",
+	}
+
+	tests := []struct {
+		name     string
+		mode     string
+		expected string
+	}{
+		{name: "inline", mode: "inline", expected: "<releaseNotes>### Fixes\n- Handle &lt;paths&gt; &amp; quotes</releaseNotes>"},
+		{name: "link", mode: "link", expected: "<releaseNotes>https://github.com/relicta-tech/mypackage/releases/tag/v1.0.0</releaseNotes>"},
+		{name: "none", mode: "none", expected: "<releaseNotes />"},
+	}
+
+	p := &ChocolateyPlugin{cmdExecutor: &MockCommandExecutor{Output: []byte("2.2.2")}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPrePublish,
+				Config: map[string]any{
+					"package_path":       "mypackage.{{version}}.nupkg",
+					"nuspec_path":        "mypackage.nuspec",
+					"pack":               true,
+					"release_notes_mode": tt.mode,
+				},
+				Context: releaseCtx,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got error: %s", resp.Error)
+			}
+
+			manifest := readZipEntries(t, "mypackage.1.0.0.nupkg")["mypackage.nuspec"]
+			if !strings.Contains(manifest, tt.expected) {
+				t.Errorf("expected manifest to contain %q, got:\n%s", tt.expected, manifest)
+			}
+		})
+	}
+}
+
+func TestBuildManifestReleaseNotesIgnoresComments(t *testing.T) {
+	doc := strings.Replace(testNuspec, "<releaseNotes />", `<!-- <releaseNotes>Commented notes</releaseNotes> -->
+    <releaseNotes>Stale notes</releaseNotes>`, 1)
+	nuspecPath := writeTestNuspec(t, t.TempDir(), doc, nil)
+
+	manifest, spec, err := buildManifest(nuspecPath, packOptions{Version: "1.0.0", ReleaseNotes: "Fresh notes"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(manifest), "<!-- <releaseNotes>Commented notes</releaseNotes> -->") {
+		t.Errorf("expected the commented-out element to be left alone, got:\n%s", manifest)
+	}
+	if spec.Metadata.ReleaseNotes != "Fresh notes" {
+		t.Errorf("expected release notes 'Fresh notes', got %q", spec.Metadata.ReleaseNotes)
+	}
+}
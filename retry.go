@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Defaults and limits for push retries.
+const (
+	defaultRetryBackoff = 2
+	maxRetries          = 10
+	maxRetryDelay       = 60 * time.Second
+)
+
+// pushAttempt records one push attempt, reported in the "attempts" output.
+type pushAttempt struct {
+	Attempt   int    `json:"attempt"`
+	Success   bool   `json:"success"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// RetryIn is the delay before the next attempt, when there is one.
+	RetryIn string `json:"retry_in,omitempty"`
+}
+
+// isTransient reports whether a push failing with the error code may succeed on retry.
+func isTransient(code string) bool {
+	return code == ErrorCodeTimeout || code == ErrorCodeServer || code == ErrorCodeNetwork
+}
+
+// retryDelay returns the delay before retrying after the given attempt:
+// backoff doubled for every earlier retry, capped at maxRetryDelay, with
+// jitter picking a delay between half and all of it.
+func retryDelay(backoff time.Duration, attempt int) time.Duration {
+	delay := backoff
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half+1)
+}
+
+// wait pauses for d or until ctx is done, using the plugin's sleep function
+// when set.
+func (p *ChocolateyPlugin) wait(ctx context.Context, d time.Duration) error {
+	if p.sleep != nil {
+		return p.sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
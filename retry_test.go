@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 1, max: 2 * time.Second},
+		{attempt: 2, max: 4 * time.Second},
+		{attempt: 3, max: 8 * time.Second},
+		{attempt: 8, max: maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				if d := retryDelay(2*time.Second, tt.attempt); d < tt.max/2 || d > tt.max {
+					t.Fatalf("expected delay between %s and %s, got %s", tt.max/2, tt.max, d)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteRetries(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	badGateway := "The remote server returned an error: (502) Bad Gateway."
+	tests := []struct {
+		name         string
+		retries      int
+		outputs      []string
+		wantSuccess  bool
+		wantError    string
+		wantAttempts []string
+	}{
+		{
+			name:         "transient failure retried",
+			retries:      3,
+			outputs:      []string{badGateway, badGateway, ""},
+			wantSuccess:  true,
+			wantAttempts: []string{ErrorCodeServer, ErrorCodeServer, ""},
+		},
+		{
+			name:         "retries exhausted",
+			retries:      2,
+			outputs:      []string{badGateway, badGateway, badGateway, ""},
+			wantError:    "choco push failed after 3 attempts: exit status 1",
+			wantAttempts: []string{ErrorCodeServer, ErrorCodeServer, ErrorCodeServer},
+		},
+		{
+			name:         "conflict not retried",
+			retries:      3,
+			outputs:      []string{"Response status code does not indicate success: 409 (Conflict).", ""},
+			wantError:    "choco push failed: exit status 1",
+			wantAttempts: []string{ErrorCodeConflict},
+		},
+		{
+			name:         "unauthorized not retried",
+			retries:      3,
+			outputs:      []string{"Response status code does not indicate success: 401 (Unauthorized).", ""},
+			wantError:    "choco push failed: exit status 1",
+			wantAttempts: []string{ErrorCodeUnauthorized},
+		},
+		{
+			name:         "no retries by default",
+			outputs:      []string{badGateway, ""},
+			wantError:    "choco push failed: exit status 1",
+			wantAttempts: []string{ErrorCodeServer},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pushes := 0
+			exec := &funcExecutor{Fn: func(_ string, args ...string) ([]byte, error) {
+				if args[0] != "push" {
+					return nil, nil
+				}
+				output := tt.outputs[pushes]
+				pushes++
+				if output != "" {
+					return []byte(output), errors.New("exit status 1")
+				}
+				return []byte("pushed"), nil
+			}}
+			var delays []time.Duration
+			p := &ChocolateyPlugin{cmdExecutor: exec, logger: discardLogger, sleep: func(_ context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			}}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"package_path":  "mypackage.{{version}}.nupkg",
+					"api_key":       "test-api-key",
+					"source":        "http://localhost:8080/",
+					"retries":       tt.retries,
+					"retry_backoff": 1,
+				},
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got %+v", tt.wantSuccess, resp)
+			}
+			if tt.wantError != "" && !strings.HasPrefix(resp.Error, tt.wantError) {
+				t.Errorf("expected error starting with '%s', got '%s'", tt.wantError, resp.Error)
+			}
+
+			attempts, _ := resp.Outputs["attempts"].([]pushAttempt)
+			if len(attempts) != len(tt.wantAttempts) || pushes != len(tt.wantAttempts) {
+				t.Fatalf("expected %d attempts, got %d pushes and %+v", len(tt.wantAttempts), pushes, attempts)
+			}
+			for i, class := range tt.wantAttempts {
+				if attempts[i].Attempt != i+1 || attempts[i].ErrorCode != class || attempts[i].Success != (class == "") {
+					t.Errorf("attempt %d: unexpected %+v", i+1, attempts[i])
+				}
+			}
+
+			// One delay per retry, doubling from retry_backoff.
+			retried := 0
+			for _, a := range attempts {
+				if a.RetryIn != "" {
+					retried++
+				}
+			}
+			if len(delays) != retried {
+				t.Fatalf("expected %d delays, got %v", retried, delays)
+			}
+			for i, d := range delays {
+				max := time.Second << i
+				if d < max/2 || d > max {
+					t.Errorf("delay %d: expected between %s and %s, got %s", i, max/2, max, d)
+				}
+				if attempts[i].RetryIn != d.String() {
+					t.Errorf("attempt %d: expected retry_in %s, got %s", i+1, d, attempts[i].RetryIn)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteRetriesNative(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	p := &ChocolateyPlugin{httpClient: server.Client(), sleep: func(context.Context, time.Duration) error { return nil }}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"package_path": "mypackage.{{version}}.nupkg",
+			"api_key":      "test-api-key",
+			"source":       server.URL + "/",
+			"push_method":  "native",
+			"retries":      1,
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || requests != 2 {
+		t.Fatalf("expected success after 2 requests, got %d requests and %+v", requests, resp)
+	}
+	attempts, _ := resp.Outputs["attempts"].([]pushAttempt)
+	if len(attempts) != 2 || attempts[0].ErrorCode != ErrorCodeServer || !attempts[1].Success {
+		t.Errorf("unexpected attempts: %+v", attempts)
+	}
+}
+
+func TestValidateRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     map[string]any
+		wantErrFld string
+	}{
+		{name: "valid", config: map[string]any{"retries": 3, "retry_backoff": 5}},
+		{name: "negative retries", config: map[string]any{"retries": -1}, wantErrFld: "retries"},
+		{name: "too many retries", config: map[string]any{"retries": 11}, wantErrFld: "retries"},
+		{name: "zero backoff", config: map[string]any{"retry_backoff": 0}, wantErrFld: "retry_backoff"},
+	}
+
+	p := &ChocolateyPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{"package_path": "mypackage.{{version}}.nupkg"}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+			resp, err := p.Validate(context.Background(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErrFld == "" {
+				if !resp.Valid {
+					t.Errorf("expected valid, got errors: %v", resp.Errors)
+				}
+				return
+			}
+			if resp.Valid || len(resp.Errors) != 1 || resp.Errors[0].Field != tt.wantErrFld {
+				t.Errorf("expected single error on '%s', got %v", tt.wantErrFld, resp.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Rollback modes applied by the on-error hook.
+const (
+	// RollbackUnlist unlists the versions pushed by this run.
+	RollbackUnlist = "unlist"
+	// RollbackNone leaves published versions in place.
+	RollbackNone = "none"
+)
+
+// Rollback outcomes reported in the "rollback" output.
+const (
+	RollbackUnlisted    = "unlisted"
+	RollbackUnsupported = "unsupported"
+	RollbackFailed      = "failed"
+	// RollbackKept marks a version overwritten with on_exists: force, which
+	// was live before this run and is left listed.
+	RollbackKept = "kept"
+)
+
+// rollbackResult is the outcome of unlisting one pushed package.
+type rollbackResult struct {
+	PackageID string `json:"package_id"`
+	Version   string `json:"version"`
+	Source    string `json:"source"`
+	Outcome   string `json:"outcome"`
+	Error     string `json:"error,omitempty"`
+}
+
+// rollback unlists every package version this plugin pushed, newest first.
+// Packages pushed by other runs or tools are never touched, since only the
+// plugin's own record is consulted, and neither are versions this run
+// overwrote.
+func (p *ChocolateyPlugin) rollback(ctx context.Context, cfg *Config, dryRun bool) *plugin.ExecuteResponse {
+	published := p.publishedPackages()
+	if len(published) == 0 {
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: "No Chocolatey packages were pushed, nothing to roll back",
+		}
+	}
+
+	results := make([]rollbackResult, len(published))
+	toUnlist := 0
+	for i := range published {
+		pkg := published[len(published)-1-i]
+		results[i] = rollbackResult{PackageID: pkg.ID, Version: pkg.Version, Source: pkg.Source}
+		if pkg.Overwritten {
+			results[i].Outcome = RollbackKept
+		} else {
+			toUnlist++
+		}
+	}
+
+	if dryRun {
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("Would unlist %d Chocolatey package version(s)", toUnlist),
+			Outputs: map[string]any{"rollback": results},
+		}
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	var failed []string
+	unlisted := 0
+	for i := range results {
+		pkg := published[len(published)-1-i]
+		r := &results[i]
+		if pkg.Overwritten {
+			continue
+		}
+
+		unlistCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := p.getNuGetClient().Unlist(unlistCtx, pkg.Source, pkg.APIKey, pkg.ID, pkg.Version)
+		cancel()
+
+		var pe *PushError
+		switch {
+		case err == nil:
+			r.Outcome = RollbackUnlisted
+			unlisted++
+			p.forgetPublished(pkg)
+		case errors.As(err, &pe) && (pe.StatusCode == http.StatusMethodNotAllowed || pe.StatusCode == http.StatusNotImplemented):
+			r.Outcome = RollbackUnsupported
+			r.Error = fmt.Sprintf("%s does not support unlisting: %v", pkg.Source, err)
+		default:
+			r.Outcome = RollbackFailed
+			r.Error = redactSecrets(fmt.Sprintf("unlist failed: %v", err), pkg.APIKey)
+			failed = append(failed, fmt.Sprintf("%s %s on %s", pkg.ID, pkg.Version, pkg.Source))
+		}
+	}
+
+	outputs := map[string]any{"rollback": results}
+	if len(failed) > 0 {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("rollback failed for %d of %d package version(s): %s", len(failed), len(results), strings.Join(failed, ", ")),
+			Outputs: outputs,
+		}
+	}
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Unlisted %d of %d Chocolatey package version(s)", unlisted, len(results)),
+		Outputs: outputs,
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// newTestPushFeed accepts native pushes and answers unlist requests with
+// deleteStatus, recording every DELETE path and API key.
+func newTestPushFeed(t *testing.T, deleteStatus int) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var deletes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			mu.Lock()
+			deletes = append(deletes, r.URL.Path+" "+r.Header.Get(nugetAPIKeyHeader))
+			mu.Unlock()
+			w.WriteHeader(deleteStatus)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), deletes...)
+	}
+}
+
+func TestExecuteOnErrorRollback(t *testing.T) {
+	tests := []struct {
+		name         string
+		rollback     string
+		deleteStatus int
+		dryRun       bool
+		wantSuccess  bool
+		wantOutcome  string
+		wantDeletes  int
+	}{
+		{name: "unlist", rollback: RollbackUnlist, deleteStatus: http.StatusNoContent, wantSuccess: true, wantOutcome: RollbackUnlisted, wantDeletes: 1},
+		{name: "unsupported", rollback: RollbackUnlist, deleteStatus: http.StatusMethodNotAllowed, wantSuccess: true, wantOutcome: RollbackUnsupported, wantDeletes: 1},
+		{name: "failed", rollback: RollbackUnlist, deleteStatus: http.StatusForbidden, wantSuccess: false, wantOutcome: RollbackFailed, wantDeletes: 1},
+		{name: "dry run", rollback: RollbackUnlist, dryRun: true, wantSuccess: true, wantDeletes: 0},
+		{name: "disabled", rollback: RollbackNone, wantSuccess: true, wantDeletes: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := chdirTemp(t)
+			writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+			server, deletes := newTestPushFeed(t, tt.deleteStatus)
+
+			p := &ChocolateyPlugin{httpClient: server.Client()}
+			config := map[string]any{
+				"package_path": "mypackage.{{version}}.nupkg",
+				"api_key":      "test-api-key",
+				"source":       server.URL + "/",
+				"push_method":  "native",
+				"rollback":     tt.rollback,
+			}
+			releaseCtx := plugin.ReleaseContext{Version: "v1.0.0"}
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookPostPublish, Config: config, Context: releaseCtx})
+			if err != nil || !resp.Success {
+				t.Fatalf("push failed: %v %+v", err, resp)
+			}
+
+			resp, err = p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookOnError, Config: config, Context: releaseCtx, DryRun: tt.dryRun})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success %v, got %+v", tt.wantSuccess, resp)
+			}
+
+			got := deletes()
+			if len(got) != tt.wantDeletes {
+				t.Fatalf("expected %d unlist requests, got %q", tt.wantDeletes, got)
+			}
+			if len(got) > 0 && got[0] != "/api/v2/package/mypackage/1.0.0 test-api-key" {
+				t.Errorf("unexpected unlist request: %s", got[0])
+			}
+
+			if tt.rollback == RollbackNone {
+				if !strings.Contains(resp.Message, "not handled") {
+					t.Errorf("expected hook to be ignored, got %+v", resp)
+				}
+				return
+			}
+			results, ok := resp.Outputs["rollback"].([]rollbackResult)
+			if !ok || len(results) != 1 {
+				t.Fatalf("expected one rollback result, got %#v", resp.Outputs["rollback"])
+			}
+			if results[0].PackageID != "mypackage" || results[0].Version != "1.0.0" || results[0].Outcome != tt.wantOutcome {
+				t.Errorf("unexpected rollback result: %+v", results[0])
+			}
+		})
+	}
+}
+
+func TestExecuteOnErrorOnlyUnlistsOwnPushes(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+	server, deletes := newTestPushFeed(t, http.StatusNoContent)
+
+	config := map[string]any{
+		"package_path": "mypackage.{{version}}.nupkg",
+		"api_key":      "test-api-key",
+		"source":       server.URL + "/",
+		"push_method":  "native",
+		"rollback":     RollbackUnlist,
+	}
+	releaseCtx := plugin.ReleaseContext{Version: "v1.0.0"}
+
+	// A plugin that pushed nothing leaves the feed alone.
+	fresh := &ChocolateyPlugin{httpClient: server.Client()}
+	resp, err := fresh.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookOnError, Config: config, Context: releaseCtx})
+	if err != nil || !resp.Success || len(deletes()) != 0 {
+		t.Fatalf("expected nothing to roll back, got %+v %v after %q", resp, err, deletes())
+	}
+
+	// Unlisted versions are forgotten, so a second on-error does nothing.
+	p := &ChocolateyPlugin{httpClient: server.Client()}
+	if resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookPostPublish, Config: config, Context: releaseCtx}); err != nil || !resp.Success {
+		t.Fatalf("push failed: %v %+v", err, resp)
+	}
+	for i := 0; i < 2; i++ {
+		if resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookOnError, Config: config, Context: releaseCtx}); err != nil || !resp.Success {
+			t.Fatalf("rollback failed: %v %+v", err, resp)
+		}
+	}
+	if got := deletes(); len(got) != 1 {
+		t.Errorf("expected a single unlist request, got %q", got)
+	}
+}
+
+func TestExecuteOnErrorScopedToRelease(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+	server, deletes := newTestPushFeed(t, http.StatusNoContent)
+
+	config := map[string]any{
+		"package_path": "mypackage.1.0.0.nupkg",
+		"api_key":      "test-api-key",
+		"source":       server.URL + "/",
+		"push_method":  "native",
+		"rollback":     RollbackUnlist,
+	}
+	push := func(p *ChocolateyPlugin, version string) {
+		t.Helper()
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookPostPublish, Config: config, Context: plugin.ReleaseContext{Version: version}})
+		if err != nil || !resp.Success {
+			t.Fatalf("push failed: %v %+v", err, resp)
+		}
+	}
+	onError := func(p *ChocolateyPlugin, version, rollback string) *plugin.ExecuteResponse {
+		t.Helper()
+		cfg := map[string]any{"rollback": rollback}
+		for k, v := range config {
+			if k != "rollback" {
+				cfg[k] = v
+			}
+		}
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookOnError, Config: cfg, Context: plugin.ReleaseContext{Version: version}})
+		if err != nil || !resp.Success {
+			t.Fatalf("on-error failed: %v %+v", err, resp)
+		}
+		return resp
+	}
+
+	// A release that never reached on-error is not rolled back by the next one.
+	p := &ChocolateyPlugin{httpClient: server.Client()}
+	push(p, "v1.0.0")
+	if resp := onError(p, "v1.0.1", RollbackUnlist); resp.Outputs["rollback"] != nil {
+		t.Errorf("expected nothing to roll back for the next release, got %+v", resp)
+	}
+
+	// Once on-error is handled, the release is forgotten even without rollback.
+	p = &ChocolateyPlugin{httpClient: server.Client()}
+	push(p, "v1.0.0")
+	onError(p, "v1.0.0", RollbackNone)
+	if resp := onError(p, "v1.0.0", RollbackUnlist); resp.Outputs["rollback"] != nil {
+		t.Errorf("expected the handled release to be forgotten, got %+v", resp)
+	}
+
+	if got := deletes(); len(got) != 0 {
+		t.Errorf("expected no unlist requests, got %q", got)
+	}
+}
+
+func TestExecuteOnErrorKeepsOverwrittenVersions(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+	server, deletes := newTestPushFeed(t, http.StatusNoContent)
+	feed := newTestFeed(t, "mypackage@1.0.0")
+	defer feed.Close()
+
+	config := map[string]any{
+		"package_path": "mypackage.{{version}}.nupkg",
+		"api_key":      "test-api-key",
+		"source":       server.URL + "/",
+		"feed_url":     feed.URL + "/api/v2",
+		"push_method":  "native",
+		"on_exists":    "force",
+		"rollback":     RollbackUnlist,
+	}
+	releaseCtx := plugin.ReleaseContext{Version: "v1.0.0"}
+
+	p := &ChocolateyPlugin{httpClient: server.Client()}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookPostPublish, Config: config, Context: releaseCtx})
+	if err != nil || !resp.Success || resp.Outputs["outcome"] != OutcomeOverwritten {
+		t.Fatalf("expected an overwrite, got %v %+v", err, resp)
+	}
+
+	resp, err = p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookOnError, Config: config, Context: releaseCtx})
+	if err != nil || !resp.Success {
+		t.Fatalf("rollback failed: %v %+v", err, resp)
+	}
+	if got := deletes(); len(got) != 0 {
+		t.Errorf("expected the overwritten version to stay listed, got %q", got)
+	}
+	results, _ := resp.Outputs["rollback"].([]rollbackResult)
+	if len(results) != 1 || results[0].Outcome != RollbackKept {
+		t.Errorf("expected a kept rollback result, got %+v", results)
+	}
+}
+
+func TestValidateRollback(t *testing.T) {
+	p := &ChocolateyPlugin{}
+	resp, err := p.Validate(context.Background(), map[string]any{
+		"package_path": "mypackage.nupkg",
+		"source":       "http://localhost:8080/",
+		"rollback":     "delete",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Valid || len(resp.Errors) != 1 || resp.Errors[0].Field != "rollback" {
+		t.Errorf("expected a rollback error, got %+v", resp.Errors)
+	}
+}
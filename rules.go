@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rule levels, mirroring the Chocolatey Community Repository package validator.
+const (
+	// RuleRequirement violations block moderation and fail the release.
+	RuleRequirement = "requirement"
+	// RuleGuideline violations are reported but should be fixed.
+	RuleGuideline = "guideline"
+	// RuleSuggestion violations are reported as hints.
+	RuleSuggestion = "suggestion"
+)
+
+// packageIDPattern matches the characters allowed in a package id.
+var packageIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// nuspecRule is a single check run against nuspec metadata.
+type nuspecRule struct {
+	ID    string
+	Level string
+	// Check returns a message describing the violation, or "" if the rule passes.
+	Check func(m nuspecMetadata) string
+}
+
+// ruleViolation is a failed rule reported in outputs.
+type ruleViolation struct {
+	RuleID  string `json:"rule_id"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// nuspecRules implements the published community repository requirements,
+// guidelines and suggestions that can be checked from the nuspec alone.
+var nuspecRules = []nuspecRule{
+	// Requirements.
+	{ID: "id-invalid", Level: RuleRequirement, Check: func(m nuspecMetadata) string {
+		if !packageIDPattern.MatchString(m.ID) {
+			return fmt.Sprintf("package id %q may only contain letters, digits, '.', '-' and '_'", m.ID)
+		}
+		return ""
+	}},
+	{ID: "authors-missing", Level: RuleRequirement, Check: requireElement("authors", func(m nuspecMetadata) string { return m.Authors })},
+	{ID: "description-missing", Level: RuleRequirement, Check: requireElement("description", func(m nuspecMetadata) string { return m.Description })},
+	{ID: "description-too-short", Level: RuleRequirement, Check: func(m nuspecMetadata) string {
+		if n := utf8.RuneCountInString(strings.TrimSpace(m.Description)); n > 0 && n < minDescriptionLength {
+			return fmt.Sprintf("<description> must be at least %d characters (got %d)", minDescriptionLength, n)
+		}
+		return ""
+	}},
+	{ID: "description-too-long", Level: RuleRequirement, Check: func(m nuspecMetadata) string {
+		if n := utf8.RuneCountInString(strings.TrimSpace(m.Description)); n > maxDescriptionLength {
+			return fmt.Sprintf("<description> must be at most %d characters (got %d)", maxDescriptionLength, n)
+		}
+		return ""
+	}},
+	{ID: "project-url-missing", Level: RuleRequirement, Check: requireElement("projectUrl", func(m nuspecMetadata) string { return m.ProjectURL })},
+	{ID: "package-source-url-missing", Level: RuleRequirement, Check: requireElement("packageSourceUrl", func(m nuspecMetadata) string { return m.PackageSourceURL })},
+	{ID: "license-url-missing", Level: RuleRequirement, Check: func(m nuspecMetadata) string {
+		if m.RequireLicenseAcceptance && strings.TrimSpace(m.LicenseURL) == "" {
+			return "<licenseUrl> is required when <requireLicenseAcceptance> is true"
+		}
+		return ""
+	}},
+	{ID: "url-invalid", Level: RuleRequirement, Check: func(m nuspecMetadata) string {
+		return checkURLs(m, func(u *url.URL) bool { return u.Scheme != "http" && u.Scheme != "https" || u.Host == "" }, "must be an absolute http(s) URL")
+	}},
+	{ID: "url-insecure", Level: RuleRequirement, Check: func(m nuspecMetadata) string {
+		return checkURLs(m, func(u *url.URL) bool { return u.Scheme == "http" }, "must use https")
+	}},
+	{ID: "tags-comma-separated", Level: RuleRequirement, Check: func(m nuspecMetadata) string {
+		if strings.Contains(m.Tags, ",") {
+			return "<tags> must be separated by spaces, not commas"
+		}
+		return ""
+	}},
+
+	// Guidelines.
+	{ID: "id-not-lowercase", Level: RuleGuideline, Check: func(m nuspecMetadata) string {
+		if m.ID != strings.ToLower(m.ID) {
+			return fmt.Sprintf("package id %q should be lowercase", m.ID)
+		}
+		return ""
+	}},
+	{ID: "title-missing", Level: RuleGuideline, Check: requireElement("title", func(m nuspecMetadata) string { return m.Title })},
+	{ID: "summary-missing", Level: RuleGuideline, Check: requireElement("summary", func(m nuspecMetadata) string { return m.Summary })},
+	{ID: "tags-missing", Level: RuleGuideline, Check: requireElement("tags", func(m nuspecMetadata) string { return m.Tags })},
+	{ID: "icon-url-missing", Level: RuleGuideline, Check: requireElement("iconUrl", func(m nuspecMetadata) string { return m.IconURL })},
+	{ID: "release-notes-missing", Level: RuleGuideline, Check: requireElement("releaseNotes", func(m nuspecMetadata) string { return m.ReleaseNotes })},
+
+	// Suggestions.
+	{ID: "docs-url-missing", Level: RuleSuggestion, Check: requireElement("docsUrl", func(m nuspecMetadata) string { return m.DocsURL })},
+	{ID: "bug-tracker-url-missing", Level: RuleSuggestion, Check: requireElement("bugTrackerUrl", func(m nuspecMetadata) string { return m.BugTrackerURL })},
+	{ID: "project-source-url-missing", Level: RuleSuggestion, Check: requireElement("projectSourceUrl", func(m nuspecMetadata) string { return m.ProjectSourceURL })},
+}
+
+// requireElement builds a check that fails when the element is empty.
+func requireElement(name string, value func(m nuspecMetadata) string) func(m nuspecMetadata) string {
+	return func(m nuspecMetadata) string {
+		if strings.TrimSpace(value(m)) == "" {
+			return fmt.Sprintf("<%s> is missing", name)
+		}
+		return ""
+	}
+}
+
+// checkURLs reports every non-empty URL element for which bad returns true.
+func checkURLs(m nuspecMetadata, bad func(u *url.URL) bool, problem string) string {
+	fields := []struct{ name, value string }{
+		{"projectUrl", m.ProjectURL},
+		{"licenseUrl", m.LicenseURL},
+		{"iconUrl", m.IconURL},
+		{"packageSourceUrl", m.PackageSourceURL},
+		{"docsUrl", m.DocsURL},
+		{"bugTrackerUrl", m.BugTrackerURL},
+		{"projectSourceUrl", m.ProjectSourceURL},
+	}
+
+	var failed []string
+	for _, f := range fields {
+		value := strings.TrimSpace(f.value)
+		if value == "" {
+			continue
+		}
+		u, err := url.Parse(value)
+		if err != nil || bad(u) {
+			failed = append(failed, "<"+f.name+">")
+		}
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", strings.Join(failed, ", "), problem)
+}
+
+// runRules checks metadata against every rule not listed in disabled.
+func runRules(m nuspecMetadata, disabled []string) []ruleViolation {
+	skip := make(map[string]bool, len(disabled))
+	for _, id := range disabled {
+		skip[id] = true
+	}
+
+	var violations []ruleViolation
+	for _, rule := range nuspecRules {
+		if skip[rule.ID] {
+			continue
+		}
+		if msg := rule.Check(m); msg != "" {
+			violations = append(violations, ruleViolation{RuleID: rule.ID, Level: rule.Level, Message: msg})
+		}
+	}
+	return violations
+}
+
+// requirementViolations returns the IDs of violated requirements.
+func requirementViolations(violations []ruleViolation) []string {
+	var ids []string
+	for _, v := range violations {
+		if v.Level == RuleRequirement {
+			ids = append(ids, v.RuleID)
+		}
+	}
+	return ids
+}
+
+// unknownRuleIDs returns the IDs that do not name a rule.
+func unknownRuleIDs(ids []string) []string {
+	known := make(map[string]bool, len(nuspecRules))
+	for _, rule := range nuspecRules {
+		known[rule.ID] = true
+	}
+
+	var unknown []string
+	for _, id := range ids {
+		if !known[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+const testCommunityNuspec = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2015/06/nuspec.xsd">
+  <metadata>
+    <id>mypackage</id>
+    <version>0.0.0</version>
+    <title>My Package</title>
+    <summary>Does useful things</summary>
+    <authors>Relicta Team</authors>
+    <projectUrl>https://github.com/relicta-tech/mypackage</projectUrl>
+    <packageSourceUrl>https://github.com/relicta-tech/mypackage/tree/main/choco</packageSourceUrl>
+    <iconUrl>https://cdn.example.com/icon.png</iconUrl>
+    <releaseNotes>https://github.com/relicta-tech/mypackage/releases</releaseNotes>
+    <description>My package does useful things on Windows machines.</description>
+    <tags>mypackage cli</tags>
+  </metadata>
+</package>
+`
+
+// completeMetadata returns metadata that satisfies every rule.
+func completeMetadata() nuspecMetadata {
+	return nuspecMetadata{
+		ID:               "mypackage",
+		Version:          "1.0.0",
+		Title:            "My Package",
+		Summary:          "Does useful things",
+		Authors:          "Relicta Team",
+		Description:      "My package does useful things on Windows machines.",
+		Tags:             "mypackage cli",
+		ProjectURL:       "https://github.com/relicta-tech/mypackage",
+		LicenseURL:       "https://github.com/relicta-tech/mypackage/blob/main/LICENSE",
+		IconURL:          "https://cdn.example.com/icon.png",
+		PackageSourceURL: "https://github.com/relicta-tech/mypackage/tree/main/choco",
+		ProjectSourceURL: "https://github.com/relicta-tech/mypackage",
+		DocsURL:          "https://docs.example.com",
+		BugTrackerURL:    "https://github.com/relicta-tech/mypackage/issues",
+		ReleaseNotes:     "Fixed bugs",
+	}
+}
+
+func TestRunRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(m *nuspecMetadata)
+		ruleID string
+		level  string
+	}{
+		{name: "invalid id", modify: func(m *nuspecMetadata) { m.ID = "my package" }, ruleID: "id-invalid", level: RuleRequirement},
+		{name: "missing authors", modify: func(m *nuspecMetadata) { m.Authors = " " }, ruleID: "authors-missing", level: RuleRequirement},
+		{name: "missing description", modify: func(m *nuspecMetadata) { m.Description = "" }, ruleID: "description-missing", level: RuleRequirement},
+		{name: "short description", modify: func(m *nuspecMetadata) { m.Description = "Too short" }, ruleID: "description-too-short", level: RuleRequirement},
+		{name: "long description", modify: func(m *nuspecMetadata) { m.Description = strings.Repeat("a", 4001) }, ruleID: "description-too-long", level: RuleRequirement},
+		{name: "missing project url", modify: func(m *nuspecMetadata) { m.ProjectURL = "" }, ruleID: "project-url-missing", level: RuleRequirement},
+		{name: "missing package source url", modify: func(m *nuspecMetadata) { m.PackageSourceURL = "" }, ruleID: "package-source-url-missing", level: RuleRequirement},
+		{name: "license acceptance without url", modify: func(m *nuspecMetadata) { m.RequireLicenseAcceptance = true; m.LicenseURL = "" }, ruleID: "license-url-missing", level: RuleRequirement},
+		{name: "relative url", modify: func(m *nuspecMetadata) { m.DocsURL = "docs/index.html" }, ruleID: "url-invalid", level: RuleRequirement},
+		{name: "http icon url", modify: func(m *nuspecMetadata) { m.IconURL = "http://cdn.example.com/icon.png" }, ruleID: "url-insecure", level: RuleRequirement},
+		{name: "comma tags", modify: func(m *nuspecMetadata) { m.Tags = "mypackage,cli" }, ruleID: "tags-comma-separated", level: RuleRequirement},
+		{name: "uppercase id", modify: func(m *nuspecMetadata) { m.ID = "MyPackage" }, ruleID: "id-not-lowercase", level: RuleGuideline},
+		{name: "missing title", modify: func(m *nuspecMetadata) { m.Title = "" }, ruleID: "title-missing", level: RuleGuideline},
+		{name: "missing summary", modify: func(m *nuspecMetadata) { m.Summary = "" }, ruleID: "summary-missing", level: RuleGuideline},
+		{name: "missing tags", modify: func(m *nuspecMetadata) { m.Tags = "" }, ruleID: "tags-missing", level: RuleGuideline},
+		{name: "missing icon", modify: func(m *nuspecMetadata) { m.IconURL = "" }, ruleID: "icon-url-missing", level: RuleGuideline},
+		{name: "missing release notes", modify: func(m *nuspecMetadata) { m.ReleaseNotes = "" }, ruleID: "release-notes-missing", level: RuleGuideline},
+		{name: "missing docs url", modify: func(m *nuspecMetadata) { m.DocsURL = "" }, ruleID: "docs-url-missing", level: RuleSuggestion},
+		{name: "missing bug tracker url", modify: func(m *nuspecMetadata) { m.BugTrackerURL = "" }, ruleID: "bug-tracker-url-missing", level: RuleSuggestion},
+		{name: "missing project source url", modify: func(m *nuspecMetadata) { m.ProjectSourceURL = "" }, ruleID: "project-source-url-missing", level: RuleSuggestion},
+	}
+
+	if violations := runRules(completeMetadata(), nil); len(violations) != 0 {
+		t.Fatalf("expected complete metadata to pass, got %v", violations)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := completeMetadata()
+			tt.modify(&m)
+
+			violations := runRules(m, nil)
+			if len(violations) != 1 {
+				t.Fatalf("expected exactly one violation, got %v", violations)
+			}
+			if violations[0].RuleID != tt.ruleID || violations[0].Level != tt.level {
+				t.Errorf("expected %s (%s), got %+v", tt.ruleID, tt.level, violations[0])
+			}
+			if violations[0].Message == "" {
+				t.Error("expected violation message")
+			}
+
+			if disabled := runRules(m, []string{tt.ruleID}); len(disabled) != 0 {
+				t.Errorf("expected disabled rule to be skipped, got %v", disabled)
+			}
+		})
+	}
+}
+
+func TestRuleIDsAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, rule := range nuspecRules {
+		if seen[rule.ID] {
+			t.Errorf("duplicate rule ID %s", rule.ID)
+		}
+		seen[rule.ID] = true
+	}
+}
+
+func TestUnknownRuleIDs(t *testing.T) {
+	unknown := unknownRuleIDs([]string{"title-missing", "no-such-rule", "another"})
+	if strings.Join(unknown, ",") != "another,no-such-rule" {
+		t.Errorf("unexpected unknown rule IDs: %v", unknown)
+	}
+}
+
+func TestExecutePrePublishRules(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	tests := []struct {
+		name        string
+		nuspec      string
+		config      map[string]any
+		wantSuccess bool
+		wantError   string
+		wantRules   []string
+	}{
+		{
+			name:        "passing nuspec",
+			nuspec:      testCommunityNuspec,
+			wantSuccess: true,
+		},
+		{
+			name:        "missing package source url fails",
+			nuspec:      testNuspec,
+			wantError:   "package-source-url-missing",
+			wantRules:   []string{"package-source-url-missing", "summary-missing", "icon-url-missing"},
+			wantSuccess: false,
+		},
+		{
+			name:        "disabled requirement passes",
+			nuspec:      testNuspec,
+			config:      map[string]any{"disabled_rules": []any{"package-source-url-missing"}},
+			wantRules:   []string{"summary-missing", "icon-url-missing"},
+			wantSuccess: true,
+		},
+		{
+			name:        "release notes injected before checks",
+			nuspec:      strings.Replace(testCommunityNuspec, "<releaseNotes>https://github.com/relicta-tech/mypackage/releases</releaseNotes>", "", 1),
+			wantSuccess: true,
+		},
+	}
+
+	p := &ChocolateyPlugin{cmdExecutor: &MockCommandExecutor{Output: []byte("2.2.2")}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeTestNuspec(t, dir, tt.nuspec, nil)
+			config := map[string]any{
+				"package_path":    "mypackage.{{version}}.nupkg",
+				"nuspec_path":     "mypackage.nuspec",
+				"validate_nuspec": true,
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPrePublish,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: "v1.0.0", ReleaseNotes: "Fixed bugs"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got %+v", tt.wantSuccess, resp)
+			}
+			if tt.wantError != "" && !strings.Contains(resp.Error, tt.wantError) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.wantError, resp.Error)
+			}
+
+			violations, _ := resp.Outputs["rule_violations"].([]ruleViolation)
+			var ids []string
+			for _, v := range violations {
+				if v.Level != RuleSuggestion {
+					ids = append(ids, v.RuleID)
+				}
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantRules, ",") {
+				t.Errorf("expected violations %v, got %v", tt.wantRules, violations)
+			}
+			if _, err := os.Stat("mypackage.1.0.0.nupkg"); !os.IsNotExist(err) {
+				t.Error("expected no package without pack enabled")
+			}
+		})
+	}
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// On-failure policies for entries in sources.
+const (
+	// OnFailureFail fails the release when the push to this source fails.
+	OnFailureFail = "fail"
+	// OnFailureContinue reports the failure without failing the release.
+	OnFailureContinue = "continue"
+)
+
+// defaultMaxParallel is the number of sources pushed concurrently by default.
+const defaultMaxParallel = 4
+
+// SourceConfig is a single feed pushed to when sources is configured.
+type SourceConfig struct {
+	URL       string
+	APIKey    string
+	APIKeyEnv string
+	// APIKeySource resolves APIKey at push time; see resolveAPIKey.
+	APIKeySource string
+	Timeout      int
+	OnFailure    string
+}
+
+// sourceResult is the outcome of pushing to one source, reported in outputs.
+type sourceResult struct {
+	Source  string `json:"source"`
+	Success bool   `json:"success"`
+	Outcome string `json:"outcome,omitempty"`
+	Output  string `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// ErrorCode and Remediation classify a failure; see classifyPushError.
+	ErrorCode   string `json:"error_code,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+	// Attempts lists every push attempt, including retries.
+	Attempts []pushAttempt `json:"attempts,omitempty"`
+}
+
+// setErrorCode records the error code of a failure and its remediation hint.
+func (r *sourceResult) setErrorCode(code string) {
+	r.ErrorCode = code
+	r.Remediation = remediation(code)
+}
+
+// parseSources parses the sources list. Entries that are not objects are
+// skipped; Validate reports them. Timeouts default to defaultTimeout.
+func parseSources(raw any, defaultTimeout int) []SourceConfig {
+	items, _ := raw.([]any)
+	sources := make([]SourceConfig, 0, len(items))
+	for _, item := range items {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		parser := helpers.NewConfigParser(entry)
+		apiKeyEnv := parser.GetString("api_key_env", "", "")
+		sources = append(sources, SourceConfig{
+			URL:          parser.GetString("url", "", ""),
+			APIKey:       parser.GetString("api_key", apiKeyEnv, ""),
+			APIKeyEnv:    apiKeyEnv,
+			APIKeySource: parser.GetString("api_key_source", "", ""),
+			Timeout:      parser.GetInt("timeout", defaultTimeout),
+			OnFailure:    parser.GetString("on_failure", "", OnFailureFail),
+		})
+	}
+	return sources
+}
+
+// validateSources reports configuration errors for each entry in sources.
+func validateSources(vb *helpers.ValidationBuilder, raw any) {
+	items, ok := raw.([]any)
+	if !ok {
+		vb.AddError("sources", "sources must be a list of objects")
+		return
+	}
+
+	for i, item := range items {
+		field := fmt.Sprintf("sources[%d]", i)
+		entry, ok := item.(map[string]any)
+		if !ok {
+			vb.AddError(field, "source must be an object")
+			continue
+		}
+		parser := helpers.NewConfigParser(entry)
+
+		if url := parser.GetString("url", "", ""); url == "" {
+			vb.AddError(field+".url", "source URL is required")
+		} else if err := validateSourceURL(url); err != nil {
+			vb.AddError(field+".url", err.Error())
+		}
+		apiKeySource := parser.GetString("api_key_source", "", "")
+		if parser.GetString("api_key", "", "") == "" && parser.GetString("api_key_env", "", "") == "" && apiKeySource == "" {
+			vb.AddError(field+".api_key", "api_key, api_key_env or api_key_source is required")
+		} else if apiKeySource != "" {
+			if _, _, err := parseAPIKeySource(apiKeySource); err != nil {
+				vb.AddError(field+".api_key_source", err.Error())
+			}
+		}
+		if parser.Has("timeout") && parser.GetInt("timeout", 0) <= 0 {
+			vb.AddError(field+".timeout", "timeout must be a positive integer")
+		}
+		switch parser.GetString("on_failure", "", OnFailureFail) {
+		case OnFailureFail, OnFailureContinue:
+		default:
+			vb.AddError(field+".on_failure", fmt.Sprintf("on_failure must be one of: %s, %s", OnFailureFail, OnFailureContinue))
+		}
+	}
+}
+
+// pushToSources pushes the package to every configured source using a
+// bounded worker pool and reports a result per source.
+func (p *ChocolateyPlugin) pushToSources(ctx context.Context, cfg *Config, packagePath, version string, dryRun bool) (*plugin.ExecuteResponse, error) {
+	// Validate every source before pushing to any of them.
+	for _, src := range cfg.Sources {
+		if err := validateSourceURL(src.URL); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Error:   fmt.Sprintf("invalid source URL %s: %v", src.URL, err),
+			}, nil
+		}
+		if src.APIKey == "" && src.APIKeySource == "" {
+			hint := "set api_key"
+			if src.APIKeyEnv != "" {
+				hint = fmt.Sprintf("set the %s environment variable", src.APIKeyEnv)
+			}
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Error:   fmt.Sprintf("API key is required for source %s: %s", src.URL, hint),
+			}, nil
+		}
+	}
+
+	if dryRun {
+		urls := make([]string, len(cfg.Sources))
+		for i, src := range cfg.Sources {
+			urls[i] = src.URL
+		}
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("Would push Chocolatey package to %d sources", len(cfg.Sources)),
+			Outputs: map[string]any{
+				"package_path": packagePath,
+				"sources":      urls,
+				"version":      version,
+				"on_exists":    cfg.OnExists,
+				"max_parallel": cfg.MaxParallel,
+				"push_method":  cfg.PushMethod,
+			},
+		}, nil
+	}
+
+	// Resolve API key sources only when actually pushing.
+	for i, src := range cfg.Sources {
+		if src.APIKeySource == "" || src.APIKey != "" {
+			continue
+		}
+		apiKey, err := p.resolveAPIKey(ctx, src.APIKeySource)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Error:   fmt.Sprintf("source %s: %v", src.URL, err),
+			}, nil
+		}
+		cfg.Sources[i].APIKey = apiKey
+	}
+
+	// Inspect package contents before pushing.
+	if resp := inspectBeforePush(cfg, packagePath, version); resp != nil {
+		return resp, nil
+	}
+
+	maxParallel := cfg.MaxParallel
+	if maxParallel <= 0 {
+		maxParallel = defaultMaxParallel
+	}
+
+	results := make([]sourceResult, len(cfg.Sources))
+	sem := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+	for i, src := range cfg.Sources {
+		wg.Add(1)
+		go func(i int, src SourceConfig) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = p.pushToSource(ctx, cfg, src, packagePath, version)
+		}(i, src)
+	}
+	wg.Wait()
+
+	var failed []string
+	var errorCode string
+	pushed := 0
+	for i, r := range results {
+		if r.Success {
+			pushed++
+		} else if cfg.Sources[i].OnFailure != OnFailureContinue {
+			failed = append(failed, r.Source)
+			if errorCode == "" {
+				errorCode = r.ErrorCode
+			}
+		}
+	}
+
+	outputs := map[string]any{
+		"package_path": packagePath,
+		"version":      version,
+		"results":      results,
+	}
+	if len(failed) > 0 {
+		setErrorCode(outputs, errorCode)
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("push failed for %d of %d source(s): %s", len(failed), len(results), strings.Join(failed, ", ")),
+			Outputs: outputs,
+		}, nil
+	}
+
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Pushed Chocolatey package %s to %d of %d sources", packagePath, pushed, len(results)),
+		Outputs: outputs,
+	}, nil
+}
+
+// pushToSource checks for an existing version and pushes the package to a
+// single source.
+func (p *ChocolateyPlugin) pushToSource(ctx context.Context, cfg *Config, src SourceConfig, packagePath, version string) sourceResult {
+	result := sourceResult{Source: src.URL}
+
+	timeout := time.Duration(src.Timeout) * time.Second
+
+	// Scope the request to this source.
+	srcCfg := *cfg
+	srcCfg.Source = src.URL
+	srcCfg.APIKey = src.APIKey
+	srcCfg.Timeout = src.Timeout
+	if len(cfg.Sources) > 0 {
+		// The feed_url override only applies to a single source.
+		srcCfg.FeedURL = ""
+	}
+
+	// Check whether the version is already published.
+	overwrite := false
+	if cfg.OnExists == OnExistsSkip || cfg.OnExists == OnExistsForce {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		exists, err := p.packageExists(checkCtx, &srcCfg, packagePath, version)
+		cancel()
+		switch {
+		case err != nil && cfg.OnExists == OnExistsSkip:
+			result.Error = fmt.Sprintf("existence check failed: %v", err)
+			result.setErrorCode(classifyPushError(err, ""))
+			return result
+		case exists && cfg.OnExists == OnExistsSkip:
+			result.Success = true
+			result.Outcome = OutcomeSkipped
+			return result
+		case exists:
+			overwrite = true
+		}
+	}
+
+	// In a container the key is passed through containerAPIKeyEnv, keeping it
+	// off the container runtime's command line.
+	var pushEnv []string
+	if cfg.PushMethod != PushMethodNative && cfg.Executor == ExecutorDocker {
+		pushEnv = []string{containerAPIKeyEnv + "=" + src.APIKey}
+	}
+
+	// Push, retrying transient failures with exponential backoff. Each
+	// attempt gets the full timeout.
+	backoff := time.Duration(cfg.RetryBackoff) * time.Second
+	logLine := p.chocoLogger(&srcCfg, src.URL)
+	var output []byte
+	var err error
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		if cfg.PushMethod == PushMethodNative {
+			output, err = p.getNuGetClient().Push(attemptCtx, src.URL, src.APIKey, packagePath)
+		} else {
+			output, err = p.runChocoStream(attemptCtx, cfg, pushEnv, logLine, p.buildPushArgs(&srcCfg, packagePath)...)
+		}
+		if err != nil && attemptCtx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("%w: %v", context.DeadlineExceeded, err)
+		}
+		cancel()
+
+		if err == nil {
+			result.Attempts = append(result.Attempts, pushAttempt{Attempt: attempt, Success: true})
+			break
+		}
+		record := pushAttempt{
+			Attempt:   attempt,
+			ErrorCode: classifyPushError(err, string(output)),
+			Error:     redactSecrets(err.Error(), src.APIKey),
+		}
+		if !isTransient(record.ErrorCode) || attempt > cfg.Retries || ctx.Err() != nil {
+			result.Attempts = append(result.Attempts, record)
+			break
+		}
+		delay := retryDelay(backoff, attempt)
+		record.RetryIn = delay.String()
+		result.Attempts = append(result.Attempts, record)
+		if p.wait(ctx, delay) != nil {
+			break
+		}
+	}
+
+	result.Output = redactSecrets(string(output), src.APIKey)
+	if err != nil {
+		failed := fmt.Sprintf("%s push failed", cfg.PushMethod)
+		if len(result.Attempts) > 1 {
+			failed += fmt.Sprintf(" after %d attempts", len(result.Attempts))
+		}
+		result.Error = redactSecrets(fmt.Sprintf("%s: %v\nOutput: %s", failed, err, string(output)), src.APIKey)
+		result.setErrorCode(result.Attempts[len(result.Attempts)-1].ErrorCode)
+		return result
+	}
+
+	// Only a successful push overwrites an existing version.
+	result.Success = true
+	result.Outcome = OutcomePushed
+	if overwrite {
+		result.Outcome = OutcomeOverwritten
+	}
+	p.recordPublished(&srcCfg, packagePath, version, overwrite)
+	return result
+}
+
+// publishedPackage is a package version pushed by the plugin.
+type publishedPackage struct {
+	ID          string
+	Version     string
+	PackagePath string
+	Source      string
+	// APIKey is the key the package was pushed with, used to unlist it.
+	APIKey string
+	// Feed is queried for the package; see feedURL.
+	Feed string
+	// Overwritten is set when the version was already on the feed and was
+	// pushed again with on_exists: force.
+	Overwritten bool
+}
+
+// recordPublished remembers a package pushed to cfg's source.
+func (p *ChocolateyPlugin) recordPublished(cfg *Config, packagePath, version string, overwritten bool) {
+	id, _ := packageID(packagePath, version)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published = append(p.published, publishedPackage{
+		ID:          id,
+		Version:     version,
+		PackagePath: packagePath,
+		Source:      cfg.Source,
+		APIKey:      cfg.APIKey,
+		Feed:        feedURL(cfg),
+		Overwritten: overwritten,
+	})
+}
+
+// forgetPublished removes pkg from the packages pushed so far.
+func (p *ChocolateyPlugin) forgetPublished(pkg publishedPackage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, published := range p.published {
+		if published == pkg {
+			p.published = append(p.published[:i], p.published[i+1:]...)
+			return
+		}
+	}
+}
+
+// publishedPackages returns the packages pushed so far, in push order.
+func (p *ChocolateyPlugin) publishedPackages() []publishedPackage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]publishedPackage(nil), p.published...)
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestParseSources(t *testing.T) {
+	t.Setenv("PROGET_API_KEY", "env-key")
+
+	sources := parseSources([]any{
+		map[string]any{"url": "https://push.chocolatey.org/", "api_key": "community-key"},
+		"not-an-object",
+		map[string]any{"url": "https://proget.example.com/nuget/choco/", "api_key_env": "PROGET_API_KEY", "timeout": 60, "on_failure": "continue"},
+	}, 300)
+
+	if len(sources) != 2 {
+		t.Fatalf("expected 2 sources, got %d", len(sources))
+	}
+	if sources[0].APIKey != "community-key" || sources[0].Timeout != 300 || sources[0].OnFailure != OnFailureFail {
+		t.Errorf("unexpected first source: %+v", sources[0])
+	}
+	if sources[1].APIKey != "env-key" || sources[1].Timeout != 60 || sources[1].OnFailure != OnFailureContinue {
+		t.Errorf("unexpected second source: %+v", sources[1])
+	}
+}
+
+func TestValidateSources(t *testing.T) {
+	tests := []struct {
+		name       string
+		sources    any
+		wantErrFld string
+	}{
+		{name: "valid", sources: []any{map[string]any{"url": "http://localhost:8080/", "api_key_env": "KEY"}}},
+		{name: "not a list", sources: "http://localhost:8080/", wantErrFld: "sources"},
+		{name: "entry not an object", sources: []any{"http://localhost:8080/"}, wantErrFld: "sources[0]"},
+		{name: "missing url", sources: []any{map[string]any{"api_key": "key"}}, wantErrFld: "sources[0].url"},
+		{name: "insecure url", sources: []any{map[string]any{"url": "http://feed.example.com/", "api_key": "key"}}, wantErrFld: "sources[0].url"},
+		{name: "missing api key", sources: []any{map[string]any{"url": "http://localhost:8080/"}}, wantErrFld: "sources[0].api_key"},
+		{name: "invalid timeout", sources: []any{map[string]any{"url": "http://localhost:8080/", "api_key": "key", "timeout": 0}}, wantErrFld: "sources[0].timeout"},
+		{name: "invalid on_failure", sources: []any{map[string]any{"url": "http://localhost:8080/", "api_key": "key", "on_failure": "ignore"}}, wantErrFld: "sources[0].on_failure"},
+	}
+
+	p := &ChocolateyPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := p.Validate(context.Background(), map[string]any{
+				"package_path": "mypackage.{{version}}.nupkg",
+				"sources":      tt.sources,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErrFld == "" {
+				if !resp.Valid {
+					t.Errorf("expected valid, got errors: %v", resp.Errors)
+				}
+				return
+			}
+			if resp.Valid || len(resp.Errors) != 1 || resp.Errors[0].Field != tt.wantErrFld {
+				t.Errorf("expected single error on '%s', got %v", tt.wantErrFld, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestExecuteMultipleSources(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		if strings.HasPrefix(r.URL.Path, "/down/") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if want := "key-" + strings.Split(r.URL.Path, "/")[1]; r.Header.Get(nugetAPIKeyHeader) != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("pushed"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	source := func(path, key, onFailure string) map[string]any {
+		return map[string]any{"url": server.URL + path, "api_key": key, "on_failure": onFailure}
+	}
+
+	tests := []struct {
+		name        string
+		sources     []any
+		wantSuccess bool
+		wantError   string
+		wantResults []bool
+	}{
+		{
+			name:        "all sources succeed",
+			sources:     []any{source("/a/", "key-a", ""), source("/b/", "key-b", ""), source("/c/", "key-c", ""), source("/d/", "key-d", "")},
+			wantSuccess: true,
+			wantResults: []bool{true, true, true, true},
+		},
+		{
+			name:        "failure with continue policy",
+			sources:     []any{source("/a/", "key-a", ""), source("/down/", "key-down", "continue")},
+			wantSuccess: true,
+			wantResults: []bool{true, false},
+		},
+		{
+			name:        "failure with fail policy",
+			sources:     []any{source("/down/", "key-down", "fail"), source("/b/", "key-b", "")},
+			wantError:   "push failed for 1 of 2 source(s): " + server.URL + "/down/",
+			wantResults: []bool{false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxInFlight = 0
+			p := &ChocolateyPlugin{httpClient: server.Client(), logger: discardLogger}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"package_path": "mypackage.{{version}}.nupkg",
+					"push_method":  "native",
+					"sources":      tt.sources,
+					"max_parallel": 2,
+				},
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got %+v", tt.wantSuccess, resp)
+			}
+			if tt.wantError != "" && resp.Error != tt.wantError {
+				t.Errorf("expected error '%s', got '%s'", tt.wantError, resp.Error)
+			}
+			if maxInFlight > 2 {
+				t.Errorf("expected at most 2 concurrent pushes, got %d", maxInFlight)
+			}
+
+			results, ok := resp.Outputs["results"].([]sourceResult)
+			if !ok || len(results) != len(tt.wantResults) {
+				t.Fatalf("expected %d results, got %v", len(tt.wantResults), resp.Outputs["results"])
+			}
+			for i, want := range tt.wantResults {
+				src := tt.sources[i].(map[string]any)
+				if results[i].Source != src["url"] || results[i].Success != want {
+					t.Errorf("result %d: expected %s success=%v, got %+v", i, src["url"], want, results[i])
+				}
+				if want && (results[i].Outcome != OutcomePushed || results[i].Output != "pushed") {
+					t.Errorf("result %d: unexpected outcome or output: %+v", i, results[i])
+				}
+				if !want && !strings.Contains(results[i].Error, "503") {
+					t.Errorf("result %d: expected 503 error, got '%s'", i, results[i].Error)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteMultipleSourcesDryRun(t *testing.T) {
+	mock := &MockCommandExecutor{}
+	p := &ChocolateyPlugin{cmdExecutor: mock}
+
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"package_path": "mypackage.{{version}}.nupkg",
+			"sources": []any{
+				map[string]any{"url": "http://localhost:8080/", "api_key": "key-a"},
+				map[string]any{"url": "http://localhost:8081/", "api_key_env": "CHOCO_TEST_UNSET_KEY"},
+			},
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success || !strings.Contains(resp.Error, "set the CHOCO_TEST_UNSET_KEY environment variable") {
+		t.Fatalf("expected missing API key error, got %+v", resp)
+	}
+
+	t.Setenv("CHOCO_TEST_UNSET_KEY", "key-b")
+	resp, err = p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"package_path": "mypackage.{{version}}.nupkg",
+			"sources": []any{
+				map[string]any{"url": "http://localhost:8080/", "api_key": "key-a"},
+				map[string]any{"url": "http://localhost:8081/", "api_key_env": "CHOCO_TEST_UNSET_KEY"},
+			},
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+	sources, _ := resp.Outputs["sources"].([]string)
+	if strings.Join(sources, ",") != "http://localhost:8080/,http://localhost:8081/" {
+		t.Errorf("unexpected sources output: %v", resp.Outputs["sources"])
+	}
+	if len(mock.Commands) != 0 {
+		t.Errorf("expected no commands in dry run, got %v", mock.Commands)
+	}
+}
+
+func TestExecuteMultipleSourcesAPIKeySource(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+	if err := os.WriteFile("proget-key", []byte("file-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := &MockCommandExecutor{Output: []byte("pushed")}
+	p := &ChocolateyPlugin{cmdExecutor: mock, logger: discardLogger}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"package_path": "mypackage.{{version}}.nupkg",
+			"sources": []any{
+				map[string]any{"url": "http://localhost:8080/", "api_key_source": "file:proget-key"},
+			},
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+	if len(mock.Commands) != 1 || !containsString(mock.Commands[0].Args, "file-key") {
+		t.Errorf("expected the key from the file to be pushed with, got %v", mock.Commands)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// outputTailSize bounds the command output kept for responses and error
+// classification. Earlier output is only streamed to the log.
+const outputTailSize = 64 << 10
+
+// runStreaming runs cmd, passing each line of its combined output to onLine
+// when set, and returns the last outputTailSize bytes of that output.
+func runStreaming(cmd *exec.Cmd, onLine func(line string)) ([]byte, error) {
+	out := &streamOutput{
+		tail:  tailBuffer{max: outputTailSize},
+		lines: lineWriter{onLine: onLine},
+	}
+	// A single writer for both streams keeps their lines in order.
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	out.lines.flush()
+	return out.tail.Bytes(), err
+}
+
+// streamOutput collects command output into a bounded tail and a line
+// callback.
+type streamOutput struct {
+	tail  tailBuffer
+	lines lineWriter
+}
+
+// Write implements io.Writer.
+func (o *streamOutput) Write(p []byte) (int, error) {
+	o.tail.Write(p)
+	o.lines.Write(p)
+	return len(p), nil
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	max     int
+	buf     []byte
+	dropped int64
+}
+
+// Write implements io.Writer, discarding the oldest bytes beyond max.
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) >= b.max {
+		b.dropped += int64(len(b.buf) + len(p) - b.max)
+		b.buf = append(b.buf[:0], p[len(p)-b.max:]...)
+		return n, nil
+	}
+	if over := len(b.buf) + len(p) - b.max; over > 0 {
+		b.dropped += int64(over)
+		b.buf = b.buf[:copy(b.buf, b.buf[over:])]
+	}
+	b.buf = append(b.buf, p...)
+	return n, nil
+}
+
+// Bytes returns the kept output. When output was discarded, the partial
+// first line is dropped as well and a note says how much is missing.
+func (b *tailBuffer) Bytes() []byte {
+	if b.dropped == 0 {
+		return b.buf
+	}
+	kept, dropped := b.buf, b.dropped
+	if i := bytes.IndexByte(kept, '\n'); i >= 0 {
+		kept, dropped = kept[i+1:], dropped+int64(i+1)
+	}
+	note := fmt.Sprintf("[%d bytes of earlier output omitted]\n", dropped)
+	return append([]byte(note), kept...)
+}
+
+// lineWriter splits output into lines for onLine. Lines longer than
+// outputTailSize are passed on in pieces.
+type lineWriter struct {
+	onLine  func(line string)
+	partial []byte
+}
+
+// Write implements io.Writer.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	if w.onLine == nil {
+		return len(p), nil
+	}
+	w.partial = append(w.partial, p...)
+	start := 0
+	for {
+		i := bytes.IndexByte(w.partial[start:], '\n')
+		if i < 0 {
+			break
+		}
+		w.onLine(strings.TrimRight(string(w.partial[start:start+i]), "\r"))
+		start += i + 1
+	}
+	w.partial = w.partial[:copy(w.partial, w.partial[start:])]
+	if len(w.partial) >= outputTailSize {
+		w.flush()
+	}
+	return len(p), nil
+}
+
+// flush passes any unterminated final line to onLine.
+func (w *lineWriter) flush() {
+	if w.onLine != nil && len(w.partial) > 0 {
+		w.onLine(strings.TrimRight(string(w.partial), "\r"))
+	}
+	w.partial = nil
+}
+
+// chocoLogger returns a line handler logging choco output for source with
+// cfg's secrets masked.
+func (p *ChocolateyPlugin) chocoLogger(cfg *Config, source string) func(line string) {
+	redact := newRedactor(cfg)
+	logger := p.getLogger()
+	return func(line string) {
+		logger.Printf("choco %s: %s", source, redact.String(line))
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestTailBuffer(t *testing.T) {
+	tests := []struct {
+		name   string
+		max    int
+		writes []string
+		want   string
+	}{
+		{name: "within limit", max: 16, writes: []string{"one\n", "two\n"}, want: "one\ntwo\n"},
+		{name: "drops oldest lines", max: 10, writes: []string{"first\n", "second\n", "third\n"}, want: "[13 bytes of earlier output omitted]\nthird\n"},
+		{name: "single large write", max: 8, writes: []string{"aaaa\nbbbb\ncc\n"}, want: "[10 bytes of earlier output omitted]\ncc\n"},
+		{name: "no newline in tail", max: 4, writes: []string{"abcdefgh"}, want: "[4 bytes of earlier output omitted]\nefgh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &tailBuffer{max: tt.max}
+			for _, w := range tt.writes {
+				b.Write([]byte(w))
+			}
+			if got := string(b.Bytes()); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := &lineWriter{onLine: func(line string) { lines = append(lines, line) }}
+	for _, chunk := range []string{"Pushing mypkg", " 1.0.0\r\nUploading", "...\n\n", "done"} {
+		w.Write([]byte(chunk))
+	}
+	w.flush()
+
+	want := []string{"Pushing mypkg 1.0.0", "Uploading...", "", "done"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("expected lines %q, got %q", want, lines)
+	}
+}
+
+func TestRealCommandExecutorRunStream(t *testing.T) {
+	var lines []string
+	output, err := (&RealCommandExecutor{}).RunStream(context.Background(), []string{"STREAM_TEST=value"}, func(line string) {
+		lines = append(lines, line)
+	}, "sh", "-c", `echo "out $STREAM_TEST"; echo err >&2; printf partial`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"out value", "err", "partial"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("expected lines %q, got %q", want, lines)
+	}
+	if string(output) != "out value\nerr\npartial" {
+		t.Errorf("unexpected output: %q", output)
+	}
+}
+
+func TestRealCommandExecutorBoundsOutput(t *testing.T) {
+	output, err := (&RealCommandExecutor{}).Run(context.Background(), "sh", "-c", `i=0; while [ $i -lt 5000 ]; do echo "line $i of progress output"; i=$((i+1)); done`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(output) > outputTailSize+64 {
+		t.Errorf("expected output bounded to about %d bytes, got %d", outputTailSize, len(output))
+	}
+	if !strings.HasPrefix(string(output), "[") || !strings.HasSuffix(string(output), "line 4999 of progress output\n") {
+		t.Errorf("expected omission note and final line, got %q...%q", output[:40], output[len(output)-40:])
+	}
+}
+
+func TestExecuteStreamsPushOutput(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	var logged bytes.Buffer
+	mock := &MockCommandExecutor{Output: []byte("Attempting to push mypackage.1.0.0.nupkg\nusing key test-api-key\nmypackage 1.0.0 was pushed successfully\n")}
+	p := &ChocolateyPlugin{cmdExecutor: mock, logger: log.New(&logged, "", 0)}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"package_path": "mypackage.{{version}}.nupkg",
+			"api_key":      "test-api-key",
+			"source":       "http://localhost:8080/",
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+
+	want := "choco http://localhost:8080/: Attempting to push mypackage.1.0.0.nupkg\n" +
+		"choco http://localhost:8080/: using key [REDACTED]\n" +
+		"choco http://localhost:8080/: mypackage 1.0.0 was pushed successfully\n"
+	if logged.String() != want {
+		t.Errorf("unexpected log:\n%s", logged.String())
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// communityGalleryURL is the base of package pages on the community repository.
+const communityGalleryURL = "https://community.chocolatey.org/packages/"
+
+// publishSummary describes one published package version, reported in the
+// "published" output of the on-success hook.
+type publishSummary struct {
+	PackageID string `json:"package_id"`
+	Version   string `json:"version"`
+	Source    string `json:"source"`
+	// InstallCommand installs exactly this version from the feed it was pushed to.
+	InstallCommand string `json:"install_command"`
+	// GalleryURL links to the package page, when the feed has one.
+	GalleryURL string `json:"gallery_url,omitempty"`
+}
+
+// newPublishSummary builds the summary of a published package.
+func newPublishSummary(pkg publishedPackage) publishSummary {
+	summary := publishSummary{
+		PackageID:      pkg.ID,
+		Version:        pkg.Version,
+		Source:         pkg.Source,
+		InstallCommand: fmt.Sprintf("choco install %s --version %s --source %s", pkg.ID, pkg.Version, pkg.Feed),
+	}
+	// choco install ignores prerelease versions without --pre.
+	if strings.Contains(pkg.Version, "-") {
+		summary.InstallCommand += " --pre"
+	}
+	if u, err := url.Parse(pkg.Source); err == nil && strings.EqualFold(u.Hostname(), communityPushHost) {
+		summary.GalleryURL = communityGalleryURL + url.PathEscape(strings.ToLower(pkg.ID)) + "/" + url.PathEscape(pkg.Version)
+	}
+	return summary
+}
+
+// publishSummaryResponse reports every package version this plugin pushed
+// during the current release, with install commands and gallery links for release notifications.
+func (p *ChocolateyPlugin) publishSummaryResponse() *plugin.ExecuteResponse {
+	published := p.publishedPackages()
+	if len(published) == 0 {
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: "No Chocolatey packages were published",
+		}
+	}
+
+	// Sources are pushed concurrently; sort for a stable summary.
+	sort.SliceStable(published, func(i, j int) bool {
+		if published[i].ID != published[j].ID {
+			return published[i].ID < published[j].ID
+		}
+		return published[i].Source < published[j].Source
+	})
+
+	summaries := make([]publishSummary, len(published))
+	commands := make([]string, len(published))
+	lines := make([]string, len(published))
+	for i, pkg := range published {
+		summaries[i] = newPublishSummary(pkg)
+		commands[i] = summaries[i].InstallCommand
+		lines[i] = fmt.Sprintf("- %s %s on %s: `%s`", pkg.ID, pkg.Version, pkg.Source, commands[i])
+		if summaries[i].GalleryURL != "" {
+			lines[i] += " " + summaries[i].GalleryURL
+		}
+	}
+
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Published %d Chocolatey package version(s)", len(published)),
+		Outputs: map[string]any{
+			"published":        summaries,
+			"install_commands": commands,
+			"summary":          strings.Join(lines, "\n"),
+		},
+	}
+}
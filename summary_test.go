@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestNewPublishSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		pkg         publishedPackage
+		wantCommand string
+		wantGallery string
+	}{
+		{
+			name:        "community repository",
+			pkg:         publishedPackage{ID: "MyPackage", Version: "1.2.3", Source: "https://push.chocolatey.org/", Feed: communityFeedURL},
+			wantCommand: "choco install MyPackage --version 1.2.3 --source https://community.chocolatey.org/api/v2/",
+			wantGallery: "https://community.chocolatey.org/packages/mypackage/1.2.3",
+		},
+		{
+			name:        "private feed",
+			pkg:         publishedPackage{ID: "mypackage", Version: "1.2.3", Source: "https://nexus.example.com/repository/choco/", Feed: "https://nexus.example.com/repository/choco/api/v2"},
+			wantCommand: "choco install mypackage --version 1.2.3 --source https://nexus.example.com/repository/choco/api/v2",
+		},
+		{
+			name:        "prerelease",
+			pkg:         publishedPackage{ID: "mypackage", Version: "1.2.0-beta0001", Source: "https://push.chocolatey.org/", Feed: communityFeedURL},
+			wantCommand: "choco install mypackage --version 1.2.0-beta0001 --source https://community.chocolatey.org/api/v2/ --pre",
+			wantGallery: "https://community.chocolatey.org/packages/mypackage/1.2.0-beta0001",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newPublishSummary(tt.pkg)
+			if got.InstallCommand != tt.wantCommand {
+				t.Errorf("expected install command %q, got %q", tt.wantCommand, got.InstallCommand)
+			}
+			if got.GalleryURL != tt.wantGallery {
+				t.Errorf("expected gallery URL %q, got %q", tt.wantGallery, got.GalleryURL)
+			}
+		})
+	}
+}
+
+func TestExecuteOnSuccessSummary(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	p := &ChocolateyPlugin{cmdExecutor: &MockCommandExecutor{Output: []byte("pushed")}, logger: discardLogger}
+	config := map[string]any{
+		"package_path": "mypackage.{{version}}.nupkg",
+		"sources": []any{
+			map[string]any{"url": "http://localhost:8080/", "api_key": "key-a"},
+			map[string]any{"url": "http://127.0.0.1:8081/choco/", "api_key": "key-b"},
+		},
+	}
+	releaseCtx := plugin.ReleaseContext{Version: "v1.0.0"}
+
+	// Nothing is reported before a push.
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookOnSuccess, Config: config, Context: releaseCtx})
+	if err != nil || !resp.Success || resp.Outputs["published"] != nil {
+		t.Fatalf("expected empty summary, got %+v %v", resp, err)
+	}
+
+	if resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookPostPublish, Config: config, Context: releaseCtx}); err != nil || !resp.Success {
+		t.Fatalf("push failed: %v %+v", err, resp)
+	}
+
+	resp, err = p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookOnSuccess, Config: config, Context: releaseCtx})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || resp.Message != "Published 2 Chocolatey package version(s)" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	commands, _ := resp.Outputs["install_commands"].([]string)
+	want := []string{
+		"choco install mypackage --version 1.0.0 --source http://127.0.0.1:8081/choco/api/v2",
+		"choco install mypackage --version 1.0.0 --source http://localhost:8080/api/v2",
+	}
+	if len(commands) != len(want) || commands[0] != want[0] || commands[1] != want[1] {
+		t.Errorf("expected install commands %q, got %q", want, commands)
+	}
+	summary, _ := resp.Outputs["summary"].(string)
+	if summary != "- mypackage 1.0.0 on http://127.0.0.1:8081/choco/: `"+want[0]+"`\n- mypackage 1.0.0 on http://localhost:8080/: `"+want[1]+"`" {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+}
+
+func TestExecuteOnSuccessSummaryScopedToRelease(t *testing.T) {
+	dir := chdirTemp(t)
+	writeTestPackage(t, dir, "mypackage.1.0.0.nupkg", "nupkg-bytes")
+
+	p := &ChocolateyPlugin{cmdExecutor: &MockCommandExecutor{Output: []byte("pushed")}, logger: discardLogger}
+	config := map[string]any{
+		"package_path": "mypackage.1.0.0.nupkg",
+		"api_key":      "test-key",
+		"source":       "http://localhost:8080/",
+	}
+	execute := func(hook plugin.Hook, version string) *plugin.ExecuteResponse {
+		t.Helper()
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{Hook: hook, Config: config, Context: plugin.ReleaseContext{Version: version}})
+		if err != nil || !resp.Success {
+			t.Fatalf("%s failed: %v %+v", hook, err, resp)
+		}
+		return resp
+	}
+
+	// A release that never reached on-success is not reported by the next one.
+	execute(plugin.HookPostPublish, "v1.0.0")
+	if resp := execute(plugin.HookOnSuccess, "v1.0.1"); resp.Outputs["published"] != nil {
+		t.Errorf("expected an empty summary for the next release, got %+v", resp)
+	}
+
+	// A release is reported once.
+	execute(plugin.HookPostPublish, "v1.0.0")
+	if resp := execute(plugin.HookOnSuccess, "v1.0.0"); resp.Message != "Published 1 Chocolatey package version(s)" {
+		t.Errorf("unexpected summary: %+v", resp)
+	}
+	if resp := execute(plugin.HookOnSuccess, "v1.0.0"); resp.Outputs["published"] != nil {
+		t.Errorf("expected the reported release to be forgotten, got %+v", resp)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// templateExtensions lists the package files rendered as templates besides the nuspec.
+var templateExtensions = []string{".ps1", ".psm1"}
+
+// templateData is the data exposed to nuspec and install script templates.
+type templateData struct {
+	Version         string
+	PreviousVersion string
+	Tag             string
+	ReleaseType     string
+	Branch          string
+	CommitSHA       string
+	RepositoryURL   string
+	RepositoryOwner string
+	RepositoryName  string
+	ReleaseNotes    string
+	Changelog       string
+	Vars            map[string]string
+}
+
+// newTemplateData builds template data from the release context and custom vars.
+func newTemplateData(releaseCtx plugin.ReleaseContext, version string, vars map[string]string) *templateData {
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	return &templateData{
+		Version:         version,
+		PreviousVersion: strings.TrimPrefix(releaseCtx.PreviousVersion, "v"),
+		Tag:             releaseCtx.TagName,
+		ReleaseType:     releaseCtx.ReleaseType,
+		Branch:          releaseCtx.Branch,
+		CommitSHA:       releaseCtx.CommitSHA,
+		RepositoryURL:   releaseCtx.RepositoryURL,
+		RepositoryOwner: releaseCtx.RepositoryOwner,
+		RepositoryName:  releaseCtx.RepositoryName,
+		ReleaseNotes:    releaseCtx.ReleaseNotes,
+		Changelog:       releaseCtx.Changelog,
+		Vars:            vars,
+	}
+}
+
+// templateFuncs are the helper functions available to templates.
+var templateFuncs = template.FuncMap{
+	// xml escapes a value for use in nuspec text or attributes.
+	"xml": xmlAttr,
+}
+
+// renderTemplate executes content as a text/template. Unknown fields and
+// missing vars are errors rather than being rendered as empty strings.
+func renderTemplate(name string, content []byte, data *templateData) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// isTemplateFile reports whether a package file is rendered as a template.
+func isTemplateFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range templateExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTemplates renders the nuspec and install scripts with placeholder data
+// so unknown placeholders are reported at validation time.
+func checkTemplates(nuspecPath string, vars map[string]string) []error {
+	data := newTemplateData(plugin.ReleaseContext{}, "0.0.0", vars)
+
+	var errs []error
+	content, err := os.ReadFile(nuspecPath)
+	if err != nil {
+		return []error{fmt.Errorf("failed to read nuspec: %w", err)}
+	}
+	if _, err := renderTemplate(filepath.Base(nuspecPath), content, data); err != nil {
+		errs = append(errs, err)
+	}
+
+	files, err := collectPackFiles(filepath.Join(filepath.Dir(nuspecPath), packToolsDir))
+	if err != nil {
+		return append(errs, err)
+	}
+	for _, f := range files {
+		if !isTemplateFile(f.target) {
+			continue
+		}
+		content, err := os.ReadFile(f.source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s: %w", f.target, err))
+			continue
+		}
+		if _, err := renderTemplate(f.target, content, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+const testTemplateNuspec = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2015/06/nuspec.xsd">
+  <metadata>
+    <id>mypackage</id>
+    <version>{{ .Version }}</version>
+    <authors>Relicta Team</authors>
+    <projectUrl>{{ .RepositoryURL }}</projectUrl>
+    <releaseNotes>{{ .RepositoryURL }}/releases/tag/{{ .Tag }}</releaseNotes>
+    <description>Built from {{ .CommitSHA }} for {{ .Vars.channel }}.</description>
+  </metadata>
+</package>
+`
+
+func TestRenderTemplate(t *testing.T) {
+	data := newTemplateData(plugin.ReleaseContext{
+		Version:       "v1.2.3",
+		TagName:       "v1.2.3",
+		CommitSHA:     "abc123",
+		RepositoryURL: "https://github.com/relicta-tech/mypackage",
+		ReleaseNotes:  "Fixed bugs",
+	}, "1.2.3", map[string]string{"channel": "stable", "notes": "a & b"})
+
+	tests := []struct {
+		name      string
+		content   string
+		expected  string
+		errSubstr string
+	}{
+		{name: "release fields", content: "{{ .Version }} {{ .Tag }} {{ .CommitSHA }}", expected: "1.2.3 v1.2.3 abc123"},
+		{name: "release notes", content: "{{ .ReleaseNotes }}", expected: "Fixed bugs"},
+		{name: "xml escaping", content: "{{ .Vars.notes | xml }}", expected: "a &amp; b"},
+		{name: "custom vars", content: "{{ .Vars.channel }}", expected: "stable"},
+		{name: "plain text", content: "$ErrorActionPreference = 'Stop'", expected: "$ErrorActionPreference = 'Stop'"},
+		{name: "unknown field", content: "{{ .Unknown }}", errSubstr: "can't evaluate field Unknown"},
+		{name: "unknown var", content: "{{ .Vars.missing }}", errSubstr: `no entry for key "missing"`},
+		{name: "legacy placeholder", content: "{{version}}", errSubstr: `function "version" not defined`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := renderTemplate("test", []byte(tt.content), data)
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Errorf("expected error containing '%s', got %v", tt.errSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestCheckTemplates(t *testing.T) {
+	t.Run("valid templates", func(t *testing.T) {
+		dir := t.TempDir()
+		nuspecPath := writeTestNuspec(t, dir, testTemplateNuspec, map[string]string{
+			"chocolateyInstall.ps1": "$url = '{{ .RepositoryURL }}/releases/download/{{ .Tag }}/app.zip'",
+		})
+		if errs := checkTemplates(nuspecPath, map[string]string{"channel": "stable"}); len(errs) != 0 {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+	})
+
+	t.Run("unknown placeholders", func(t *testing.T) {
+		dir := t.TempDir()
+		nuspecPath := writeTestNuspec(t, dir, testTemplateNuspec, map[string]string{
+			"chocolateyInstall.ps1": "$checksum = '{{ .Checksum }}'",
+			"readme.txt":            "{{ .NotRendered }}",
+		})
+		errs := checkTemplates(nuspecPath, nil)
+		if len(errs) != 2 {
+			t.Fatalf("expected 2 errors, got %v", errs)
+		}
+		if !strings.Contains(errs[0].Error(), `"channel"`) {
+			t.Errorf("expected missing var error for nuspec, got %v", errs[0])
+		}
+		if !strings.Contains(errs[1].Error(), "Checksum") {
+			t.Errorf("expected unknown field error for install script, got %v", errs[1])
+		}
+	})
+}
+
+func TestPackNuspecWithTemplate(t *testing.T) {
+	dir := t.TempDir()
+	nuspecPath := writeTestNuspec(t, dir, testTemplateNuspec, map[string]string{
+		"chocolateyInstall.ps1": "$url = '{{ .RepositoryURL }}/releases/download/{{ .Tag }}/app.zip'",
+		"app.config":            "{{ .NotRendered }}",
+	})
+	outputPath := filepath.Join(dir, "mypackage.2.0.0.nupkg")
+
+	data := newTemplateData(plugin.ReleaseContext{
+		TagName:       "v2.0.0",
+		CommitSHA:     "abc123",
+		RepositoryURL: "https://github.com/relicta-tech/mypackage",
+	}, "2.0.0", map[string]string{"channel": "stable"})
+
+	if _, err := packNuspec(nuspecPath, outputPath, packOptions{Version: "2.0.0", Template: data}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := readZipEntries(t, outputPath)
+	manifest := entries["mypackage.nuspec"]
+	for _, want := range []string{
+		"<version>2.0.0</version>",
+		"<projectUrl>https://github.com/relicta-tech/mypackage</projectUrl>",
+		"<description>Built from abc123 for stable.</description>",
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("expected manifest to contain '%s', got:\n%s", want, manifest)
+		}
+	}
+
+	if got := entries["tools/chocolateyInstall.ps1"]; got != "$url = 'https://github.com/relicta-tech/mypackage/releases/download/v2.0.0/app.zip'" {
+		t.Errorf("unexpected rendered install script: %s", got)
+	}
+	if got := entries["tools/app.config"]; got != "{{ .NotRendered }}" {
+		t.Errorf("expected non-script files to be copied verbatim, got: %s", got)
+	}
+}
+
+func TestValidateTemplatePlaceholders(t *testing.T) {
+	dir := t.TempDir()
+	writeTestNuspec(t, dir, testTemplateNuspec, nil)
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	p := &ChocolateyPlugin{}
+	config := map[string]any{
+		"package_path": "mypackage.{{version}}.nupkg",
+		"source":       "http://localhost:8080/",
+		"nuspec_path":  "mypackage.nuspec",
+		"pack":         true,
+		"template":     true,
+	}
+
+	resp, err := p.Validate(context.Background(), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Valid {
+		t.Fatal("expected unknown var to fail validation")
+	}
+	if resp.Errors[0].Field != "template" || !strings.Contains(resp.Errors[0].Message, `"channel"`) {
+		t.Errorf("unexpected validation errors: %v", resp.Errors)
+	}
+
+	config["vars"] = map[string]any{"channel": "stable"}
+	resp, err = p.Validate(context.Background(), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Valid {
+		t.Errorf("expected valid config, got errors: %v", resp.Errors)
+	}
+}
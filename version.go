@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Package fix version modes.
+const (
+	// PackageFixNone publishes the release version as is.
+	PackageFixNone = "none"
+	// PackageFixDate appends the release date as a fourth version segment.
+	PackageFixDate = "date"
+	// PackageFixCounter appends package_fix_counter as a fourth version segment.
+	PackageFixCounter = "counter"
+)
+
+// maxPrereleaseLength is the longest prerelease label Chocolatey accepts.
+const maxPrereleaseLength = 20
+
+// prereleaseIdentifierPattern matches a single SemVer prerelease identifier.
+var prereleaseIdentifierPattern = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+// versionOptions controls how a release version maps to a Chocolatey version.
+type versionOptions struct {
+	// FixMode is one of the PackageFix* modes.
+	FixMode string
+	// FixCounter is the fourth segment used by PackageFixCounter.
+	FixCounter int
+	// Now is the release time used by PackageFixDate.
+	Now time.Time
+}
+
+// normalizeVersion maps a SemVer 2 release version to a version Chocolatey
+// accepts: build metadata is dropped, the prerelease becomes a single SemVer 1
+// label with numeric identifiers zero-padded to keep their sort order, and an
+// optional package fix segment is added.
+func normalizeVersion(raw string, opts versionOptions) (string, error) {
+	version := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(raw), "v"), "V")
+	if version == "" {
+		return "", fmt.Errorf("version cannot be empty")
+	}
+
+	// Build metadata has no Chocolatey equivalent.
+	if i := strings.Index(version, "+"); i >= 0 {
+		version = version[:i]
+	}
+
+	core, prerelease, hasPrerelease := strings.Cut(version, "-")
+	if hasPrerelease && prerelease == "" {
+		return "", fmt.Errorf("version %q has an empty prerelease", raw)
+	}
+
+	segments := strings.Split(core, ".")
+	if len(segments) > 4 {
+		return "", fmt.Errorf("version %q has more than four segments", raw)
+	}
+	for _, s := range segments {
+		if _, err := strconv.ParseUint(s, 10, 32); err != nil {
+			return "", fmt.Errorf("version %q has a non-numeric segment %q", raw, s)
+		}
+	}
+	for len(segments) < 3 {
+		segments = append(segments, "0")
+	}
+
+	switch opts.FixMode {
+	case "", PackageFixNone:
+	case PackageFixDate, PackageFixCounter:
+		if len(segments) == 4 {
+			return "", fmt.Errorf("version %q already has a fourth segment for the package fix version", raw)
+		}
+		fix := opts.Now.UTC().Format("20060102")
+		if opts.FixMode == PackageFixCounter {
+			if opts.FixCounter <= 0 {
+				return "", fmt.Errorf("package fix counter must be a positive integer")
+			}
+			fix = strconv.Itoa(opts.FixCounter)
+		}
+		segments = append(segments, fix)
+	default:
+		return "", fmt.Errorf("unknown package fix version mode %q", opts.FixMode)
+	}
+
+	normalized := strings.Join(segments, ".")
+	if prerelease == "" {
+		return normalized, nil
+	}
+
+	label, err := normalizePrerelease(prerelease)
+	if err != nil {
+		return "", fmt.Errorf("version %q: %w", raw, err)
+	}
+	return normalized + "-" + label, nil
+}
+
+// maxPrereleaseNumber is the largest numeric prerelease identifier that fits
+// in the four digits it is padded to.
+const maxPrereleaseNumber = 9999
+
+// normalizePrerelease converts a SemVer 2 prerelease into a SemVer 1 label: a
+// word followed by its numeric identifiers, each padded to four digits. Only
+// prereleases that map back to a single SemVer 2 prerelease are accepted, so
+// two releases never share a package version; alpha.beta and alphabeta, or
+// rc.1 and rc0001, would otherwise both become the same label.
+func normalizePrerelease(prerelease string) (string, error) {
+	ids := strings.Split(prerelease, ".")
+	for _, id := range ids {
+		if !prereleaseIdentifierPattern.MatchString(id) {
+			return "", fmt.Errorf("invalid prerelease identifier %q", id)
+		}
+	}
+
+	word, numbers := ids[0], ids[1:]
+	if c := word[0]; !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+		return "", fmt.Errorf("prerelease %q must start with a letter", prerelease)
+	}
+	digits := len(word) - len(strings.TrimRight(word, "0123456789"))
+	if len(numbers) > 0 && digits > 0 {
+		return "", fmt.Errorf("prerelease %q is ambiguous once normalized: %q must not end in a digit when numbers follow it", prerelease, word)
+	}
+	if len(numbers) == 0 && digits >= 4 {
+		return "", fmt.Errorf("prerelease %q is ambiguous once normalized: it must not end in four or more digits", prerelease)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(word)
+	for _, id := range numbers {
+		n, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("prerelease %q is ambiguous once normalized: identifier %q follows %q but is not a number", prerelease, id, word)
+		}
+		if n > maxPrereleaseNumber || id != strconv.FormatUint(n, 10) {
+			return "", fmt.Errorf("prerelease identifier %q must be a number from 0 to %d without leading zeros", id, maxPrereleaseNumber)
+		}
+		fmt.Fprintf(&sb, "%04d", n)
+	}
+
+	label := sb.String()
+	if len(label) > maxPrereleaseLength {
+		return "", fmt.Errorf("prerelease %q is longer than %d characters once normalized (%s)", prerelease, maxPrereleaseLength, label)
+	}
+	return label, nil
+}
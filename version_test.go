@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestNormalizeVersion(t *testing.T) {
+	release := time.Date(2026, 10, 16, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		version   string
+		opts      versionOptions
+		expected  string
+		errSubstr string
+	}{
+		{name: "plain", version: "1.2.3", expected: "1.2.3"},
+		{name: "v prefix", version: "v1.2.3", expected: "1.2.3"},
+		{name: "two segments padded", version: "1.2", expected: "1.2.0"},
+		{name: "four segments kept", version: "1.2.3.4", expected: "1.2.3.4"},
+		{name: "build metadata dropped", version: "1.2.3+build.42", expected: "1.2.3"},
+		{name: "simple prerelease", version: "1.2.3-beta", expected: "1.2.3-beta"},
+		{name: "dotted prerelease", version: "1.2.3-beta.2", expected: "1.2.3-beta0002"},
+		{name: "prerelease with hyphen", version: "1.2.3-rc-fix.10+sha.abc", expected: "1.2.3-rc-fix0010"},
+		{name: "date fix", version: "v1.2.3", opts: versionOptions{FixMode: PackageFixDate, Now: release}, expected: "1.2.3.20261016"},
+		{name: "date fix uses UTC", version: "1.2.3", opts: versionOptions{FixMode: PackageFixDate, Now: release.In(time.FixedZone("UTC+2", 2*3600))}, expected: "1.2.3.20261016"},
+		{name: "counter fix", version: "1.2.3", opts: versionOptions{FixMode: PackageFixCounter, FixCounter: 2}, expected: "1.2.3.2"},
+		{name: "fix before prerelease", version: "1.2.3-beta.1", opts: versionOptions{FixMode: PackageFixCounter, FixCounter: 1}, expected: "1.2.3.1-beta0001"},
+		{name: "empty", version: "", errSubstr: "cannot be empty"},
+		{name: "non-numeric", version: "1.x.3", errSubstr: "non-numeric segment"},
+		{name: "too many segments", version: "1.2.3.4.5", errSubstr: "more than four segments"},
+		{name: "fix with four segments", version: "1.2.3.4", opts: versionOptions{FixMode: PackageFixDate, Now: release}, errSubstr: "already has a fourth segment"},
+		{name: "counter not set", version: "1.2.3", opts: versionOptions{FixMode: PackageFixCounter}, errSubstr: "positive integer"},
+		{name: "unknown fix mode", version: "1.2.3", opts: versionOptions{FixMode: "weekly"}, errSubstr: "unknown package fix version mode"},
+		{name: "prerelease starting with digit", version: "1.2.3-1.beta", errSubstr: "must start with a letter"},
+		{name: "prerelease too long", version: "1.2.3-preview-nightly.1.2.3", errSubstr: "longer than 20 characters"},
+		{name: "numbers after a word", version: "1.2.3-rc.1.10", expected: "1.2.3-rc00010010"},
+		{name: "word with short trailing digits", version: "1.2.3-beta2", expected: "1.2.3-beta2"},
+		{name: "two words would merge", version: "1.2.3-alpha.beta", errSubstr: "is not a number"},
+		{name: "word after number would merge", version: "1.2.3-rc.1.fix", errSubstr: "is not a number"},
+		{name: "lone word ending in padded digits", version: "1.2.3-rc0001", errSubstr: "four or more digits"},
+		{name: "word ending in digit before numbers", version: "1.2.3-rc1.1", errSubstr: "must not end in a digit"},
+		{name: "number too large to pad", version: "1.2.3-rc.10000", errSubstr: "from 0 to 9999"},
+		{name: "number with leading zero", version: "1.2.3-rc.01", errSubstr: "without leading zeros"},
+		{name: "empty prerelease", version: "1.0.0-", errSubstr: "empty prerelease"},
+		{name: "empty prerelease with build metadata", version: "1.0.0-+build", errSubstr: "empty prerelease"},
+		{name: "empty prerelease identifier", version: "1.2.3-beta..1", errSubstr: "invalid prerelease identifier"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeVersion(tt.version, tt.opts)
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Errorf("expected error containing '%s', got %q, %v", tt.errSubstr, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("normalizeVersion(%s) = %s, want %s", tt.version, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecuteNormalizesVersion(t *testing.T) {
+	p := &ChocolateyPlugin{now: func() time.Time { return time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC) }}
+
+	tests := []struct {
+		name          string
+		config        map[string]any
+		version       string
+		expectedPath  string
+		expectedError string
+	}{
+		{
+			name:         "prerelease with build metadata",
+			config:       map[string]any{},
+			version:      "v2.0.0-rc.1+build.7",
+			expectedPath: "mypackage.2.0.0-rc0001.nupkg",
+		},
+		{
+			name:         "date package fix",
+			config:       map[string]any{"package_fix_version": "date"},
+			version:      "v2.0.0",
+			expectedPath: "mypackage.2.0.0.20261016.nupkg",
+		},
+		{
+			name:         "version override",
+			config:       map[string]any{"version": "2.0.1"},
+			version:      "v2.0.0",
+			expectedPath: "mypackage.2.0.1.nupkg",
+		},
+		{
+			name:          "unrepresentable version",
+			config:        map[string]any{},
+			version:       "v2.0.0-0.preview",
+			expectedError: "invalid version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{
+				"package_path": "mypackage.{{version}}.nupkg",
+				"api_key":      "test-key",
+				"source":       "http://localhost:8080/",
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: tt.version},
+				DryRun:  true,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.expectedError != "" {
+				if resp.Success || !strings.Contains(resp.Error, tt.expectedError) {
+					t.Errorf("expected error containing '%s', got %+v", tt.expectedError, resp)
+				}
+				return
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got error: %s", resp.Error)
+			}
+			if resp.Outputs["package_path"] != tt.expectedPath {
+				t.Errorf("expected package_path '%s', got '%v'", tt.expectedPath, resp.Outputs["package_path"])
+			}
+		})
+	}
+}
+
+func TestExecuteDateFixTakenOncePerRelease(t *testing.T) {
+	now := time.Date(2026, 10, 16, 23, 59, 0, 0, time.UTC)
+	p := &ChocolateyPlugin{now: func() time.Time { return now }}
+
+	packagePath := func(version string) any {
+		t.Helper()
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: plugin.HookPostPublish,
+			Config: map[string]any{
+				"package_path":        "mypackage.{{version}}.nupkg",
+				"api_key":             "test-key",
+				"source":              "http://localhost:8080/",
+				"package_fix_version": "date",
+			},
+			Context: plugin.ReleaseContext{Version: version, CommitSHA: "abc123"},
+			DryRun:  true,
+		})
+		if err != nil || !resp.Success {
+			t.Fatalf("unexpected failure: %+v, %v", resp, err)
+		}
+		return resp.Outputs["package_path"]
+	}
+
+	if got := packagePath("v2.0.0"); got != "mypackage.2.0.0.20261016.nupkg" {
+		t.Fatalf("unexpected package_path %v", got)
+	}
+
+	// A later hook of the same release keeps the date after midnight.
+	now = now.Add(2 * time.Minute)
+	if got := packagePath("v2.0.0"); got != "mypackage.2.0.0.20261016.nupkg" {
+		t.Errorf("expected the release date to be kept, got %v", got)
+	}
+
+	// The next release takes the date again.
+	if got := packagePath("v2.0.1"); got != "mypackage.2.0.1.20261017.nupkg" {
+		t.Errorf("expected a new date for the next release, got %v", got)
+	}
+}
+
+func TestValidateVersionOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     map[string]any
+		wantErrFld string
+	}{
+		{name: "valid date mode", config: map[string]any{"package_fix_version": "date"}},
+		{name: "valid counter mode", config: map[string]any{"package_fix_version": "counter", "package_fix_counter": 3}},
+		{name: "valid override", config: map[string]any{"version": "1.2.3-beta.1"}},
+		{name: "unknown mode", config: map[string]any{"package_fix_version": "weekly"}, wantErrFld: "package_fix_version"},
+		{name: "counter without value", config: map[string]any{"package_fix_version": "counter"}, wantErrFld: "package_fix_counter"},
+		{name: "unrepresentable override", config: map[string]any{"version": "1.2.3-this.is.a.very.long.prerelease"}, wantErrFld: "version"},
+		{name: "override with fix segment conflict", config: map[string]any{"version": "1.2.3.4", "package_fix_version": "date"}, wantErrFld: "version"},
+	}
+
+	p := &ChocolateyPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{
+				"package_path": "mypackage.{{version}}.nupkg",
+				"source":       "http://localhost:8080/",
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+
+			resp, err := p.Validate(context.Background(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErrFld == "" {
+				if !resp.Valid {
+					t.Errorf("expected valid, got errors: %v", resp.Errors)
+				}
+				return
+			}
+			if resp.Valid || len(resp.Errors) != 1 || resp.Errors[0].Field != tt.wantErrFld {
+				t.Errorf("expected single error on '%s', got %v", tt.wantErrFld, resp.Errors)
+			}
+		})
+	}
+}